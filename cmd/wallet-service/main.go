@@ -4,10 +4,19 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"wallet-service/internal/accuracy"
+	"wallet-service/internal/apikey"
+	"wallet-service/internal/auth"
 	"wallet-service/internal/cache"
+	"wallet-service/internal/catalogue"
+	"wallet-service/internal/cors"
 	"wallet-service/internal/database"
+	"wallet-service/internal/jobs"
 	"wallet-service/internal/migrations"
+	"wallet-service/internal/predictor"
+	"wallet-service/internal/rates"
 	"wallet-service/internal/service"
+	"wallet-service/internal/trading"
 
 	"github.com/pkg/errors"
 	"wallet-service/internal/config"
@@ -35,7 +44,48 @@ func main() {
 		log.Fatal(errors.Wrap(err, "error in cache initiating"))
 	}
 
-	router := service.InitRouter(db, redisCache, cfg)
+	rateProvider, err := rates.InitRateProvider(cfg)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "error in rate provider initiating"))
+	}
+
+	currencyCatalogue, err := catalogue.InitCatalogue(db, cfg)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "error in currency catalogue initiating"))
+	}
+
+	tradingRules, err := trading.InitEngine(db, cfg)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "error in trading rules initiating"))
+	}
+
+	corsPolicy, err := cors.InitPolicy(cfg)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "error in cors policy initiating"))
+	}
+
+	verifier, err := auth.InitVerifier(cfg)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "error in auth initiating"))
+	}
+
+	apiKeys, err := apikey.InitStore(db, cfg)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "error in api keys initiating"))
+	}
+
+	predictors, err := predictor.InitModels(cfg)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "error in predictor initiating"))
+	}
+
+	predictionAccuracy := accuracy.InitTracker(db, rateProvider, cfg)
+	predictionJobs := jobs.InitQueue(db, cfg)
+
+	router := service.InitRouter(
+		db, redisCache, rateProvider, currencyCatalogue, tradingRules, corsPolicy, verifier, apiKeys,
+		predictors, predictionAccuracy, predictionJobs, cfg,
+	)
 
 	log.Println("service starting...")
 	err = http.ListenAndServe(":8080", router)
@@ -5,11 +5,14 @@ go 1.19
 require (
 	github.com/go-chi/chi/v5 v5.0.7
 	github.com/go-redis/redis/v9 v9.0.0-rc.1
+	github.com/golang-jwt/jwt/v4 v4.5.2
 	github.com/golang-migrate/migrate/v4 v4.15.2
 	github.com/jmoiron/sqlx v1.3.5
 	github.com/lib/pq v1.10.7
 	github.com/nleeper/goment v1.4.4
 	github.com/pkg/errors v0.9.1
+	github.com/shopspring/decimal v1.4.0
+	golang.org/x/text v0.13.0
 )
 
 require (
@@ -20,4 +23,5 @@ require (
 	github.com/stretchr/testify v1.8.1 // indirect
 	github.com/tkuchiki/go-timezone v0.2.0 // indirect
 	go.uber.org/atomic v1.7.0 // indirect
+	golang.org/x/sys v0.10.0 // indirect
 )
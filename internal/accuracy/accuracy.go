@@ -0,0 +1,268 @@
+package accuracy
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"wallet-service/internal/config"
+	"wallet-service/internal/currency_helpers"
+	"wallet-service/internal/rates"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// unrealizedAfter is how long a prediction waits for the rate of its date
+// before it is evaluated without one, like for days the provider publishes no rates on.
+const unrealizedAfter = 7 * 24 * time.Hour
+
+// Accuracy of the predictions of a model for the pair made horizon days ahead,
+// MAE is in the second currency and MAPE in percent.
+type Accuracy struct {
+	Base    currency_helpers.CurrencyCode `json:"base" db:"base_currency"`
+	Second  currency_helpers.CurrencyCode `json:"second" db:"second_currency"`
+	Model   string                        `json:"model" db:"model"`
+	Horizon int                           `json:"horizon" db:"horizon"`
+	Count   int                           `json:"count" db:"count"`
+	MAE     currency_helpers.Decimal      `json:"mae" db:"mae"`
+	MAPE    currency_helpers.Decimal      `json:"mape" db:"mape"`
+}
+
+// Filter narrows the accuracy report, zero fields are not filtered on.
+type Filter struct {
+	Base   currency_helpers.CurrencyCode
+	Second currency_helpers.CurrencyCode
+	Model  string
+}
+
+type prediction struct {
+	Base         currency_helpers.CurrencyCode `db:"base_currency"`
+	Second       currency_helpers.CurrencyCode `db:"second_currency"`
+	Model        string                        `db:"model"`
+	ModelVersion string                        `db:"model_version"`
+	GeneratedAt  time.Time                     `db:"generated_at"`
+	ForecastDate time.Time                     `db:"forecast_date"`
+	TargetDate   time.Time                     `db:"target_date"`
+	Horizon      int                           `db:"horizon"`
+	Rate         currency_helpers.Decimal      `db:"rate"`
+	Lower        *currency_helpers.Decimal     `db:"lower"`
+	Upper        *currency_helpers.Decimal     `db:"upper"`
+}
+
+// Tracker keeps generated predictions in Postgres and periodically fills in
+// the rates realized on their dates, so the models can be compared by their errors.
+type Tracker struct {
+	db           *sqlx.DB
+	rateProvider rates.RateProvider
+	cfg          *config.Config
+}
+
+func InitTracker(db *sqlx.DB, rateProvider rates.RateProvider, cfg *config.Config) *Tracker {
+	t := &Tracker{
+		db:           db,
+		rateProvider: rateProvider,
+		cfg:          cfg,
+	}
+
+	if cfg.PredictionAccuracyInterval > 0 {
+		go t.evaluateLoop(cfg.PredictionAccuracyInterval)
+	}
+
+	return t
+}
+
+func (t *Tracker) evaluateLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		err := t.Evaluate(context.Background())
+		if err != nil {
+			log.Printf("error in evaluate predictions: %s", err.Error())
+		}
+	}
+}
+
+// Record stores the points of the forecast for the pair.
+func (t *Tracker) Record(
+	ctx context.Context,
+	base currency_helpers.CurrencyCode,
+	second currency_helpers.CurrencyCode,
+	forecast *currency_helpers.Forecast,
+) error {
+	if len(forecast.Points) == 0 {
+		return nil
+	}
+
+	tx, err := t.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareNamedContext(ctx, `
+		insert into predictions (
+			base_currency, second_currency, model, model_version, generated_at,
+			forecast_date, target_date, horizon, rate, lower, upper
+		)
+		values (
+			:base_currency, :second_currency, :model, :model_version, :generated_at,
+			:forecast_date, :target_date, :horizon, :rate, :lower, :upper
+		)
+		on conflict (base_currency, second_currency, model, forecast_date, target_date) do nothing;
+	`)
+	if err != nil {
+		return errors.Wrap(err, "prepare insert prediction")
+	}
+	defer stmt.Close()
+
+	forecastDate := forecast.Points[0].Date.Time
+	for i, point := range forecast.Points {
+		_, err = stmt.ExecContext(ctx, prediction{
+			Base:         base,
+			Second:       second,
+			Model:        forecast.Model,
+			ModelVersion: forecast.ModelVersion,
+			GeneratedAt:  forecast.GeneratedAt,
+			ForecastDate: forecastDate,
+			TargetDate:   point.Date.Time,
+			Horizon:      i + 1,
+			Rate:         point.Rate,
+			Lower:        point.Lower,
+			Upper:        point.Upper,
+		})
+		if err != nil {
+			return errors.Wrap(err, "insert prediction")
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errors.Wrap(err, "commit transaction")
+	}
+
+	return nil
+}
+
+// Evaluate fills in realized rates of the predictions whose dates have passed.
+// Rates are published for the previous day, so today's predictions wait until tomorrow.
+func (t *Tracker) Evaluate(ctx context.Context) error {
+	pending := make([]struct {
+		Base   currency_helpers.CurrencyCode `db:"base_currency"`
+		Second currency_helpers.CurrencyCode `db:"second_currency"`
+		First  time.Time                     `db:"first_date"`
+		Last   time.Time                     `db:"last_date"`
+	}, 0)
+	dbCtx, cancel := context.WithTimeout(ctx, t.cfg.DBTimeout)
+	defer cancel()
+	err := t.db.SelectContext(dbCtx, &pending, `
+		select base_currency, second_currency, min(target_date) as first_date, max(target_date) as last_date
+		from predictions
+		where evaluated_at is null and target_date < current_date
+		group by base_currency, second_currency;
+	`)
+	if err != nil {
+		return errors.Wrap(err, "select pending predictions")
+	}
+
+	for _, pair := range pending {
+		// one pair failing to load is retried on the next run without holding back the others
+		err = t.evaluatePair(ctx, pair.Base, pair.Second, pair.First, pair.Last)
+		if err != nil {
+			log.Printf("error in evaluate %s/%s predictions: %s", pair.Base, pair.Second, err.Error())
+		}
+	}
+
+	dbCtx, cancel = context.WithTimeout(ctx, t.cfg.DBTimeout)
+	defer cancel()
+	_, err = t.db.ExecContext(dbCtx, `
+		update predictions set evaluated_at = now()
+		where evaluated_at is null and target_date < $1;
+	`, time.Now().Add(-unrealizedAfter))
+	if err != nil {
+		return errors.Wrap(err, "expire unrealized predictions")
+	}
+
+	return nil
+}
+
+func (t *Tracker) evaluatePair(
+	ctx context.Context,
+	base currency_helpers.CurrencyCode,
+	second currency_helpers.CurrencyCode,
+	start time.Time,
+	end time.Time,
+) error {
+	timeline, err := t.rateProvider.TimeSeries(ctx, base, second, start, end)
+	if err != nil {
+		return errors.Wrap(err, "get realized rates")
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, t.cfg.DBTimeout)
+	defer cancel()
+	tx, err := t.db.BeginTxx(dbCtx, nil)
+	if err != nil {
+		return errors.Wrap(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	for date, rate := range timeline.ToResultTimelineRates(second).Rates {
+		if !rate.IsPositive() {
+			continue
+		}
+		_, err = tx.ExecContext(dbCtx, `
+			update predictions set actual = $1, evaluated_at = now()
+			where base_currency = $2 and second_currency = $3 and target_date = $4 and evaluated_at is null;
+		`, rate, base, second, date.Time)
+		if err != nil {
+			return errors.Wrap(err, "update prediction")
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errors.Wrap(err, "commit transaction")
+	}
+
+	return nil
+}
+
+// Report returns the errors of the evaluated predictions by pair, model and horizon.
+func (t *Tracker) Report(ctx context.Context, filter Filter) ([]Accuracy, error) {
+	conditions := []string{"actual is not null"}
+	args := make([]interface{}, 0)
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Base.IsSet() {
+		where("base_currency = $%d", filter.Base)
+	}
+	if filter.Second.IsSet() {
+		where("second_currency = $%d", filter.Second)
+	}
+	if filter.Model != "" {
+		where("model = $%d", filter.Model)
+	}
+
+	query := `
+		select base_currency, second_currency, model, horizon, count(*) as count,
+			round(avg(abs(rate - actual)), 8) as mae,
+			round(avg(abs(rate - actual) / actual) * 100, 4) as mape
+		from predictions
+		where ` + strings.Join(conditions, " and ") + `
+		group by base_currency, second_currency, model, horizon
+		order by base_currency, second_currency, model, horizon;
+	`
+
+	result := make([]Accuracy, 0)
+	err := t.db.SelectContext(ctx, &result, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "select prediction accuracy")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,296 @@
+package accuracy
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+	"wallet-service/internal/config"
+	"wallet-service/internal/currency_helpers"
+	"wallet-service/internal/rates"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+// fakeProvider realizes the rates of the second currency on the given dates only.
+type fakeProvider struct {
+	rates.RateProvider
+	realized map[time.Time]string
+}
+
+func (p *fakeProvider) TimeSeries(
+	_ context.Context,
+	base currency_helpers.CurrencyCode,
+	second currency_helpers.CurrencyCode,
+	start time.Time,
+	end time.Time,
+) (*currency_helpers.CurrencyTimelineRates, error) {
+	series := make(map[currency_helpers.CustomTime]map[currency_helpers.CurrencyCode]currency_helpers.Decimal)
+	for date, rate := range p.realized {
+		d, err := currency_helpers.ParseDecimal(rate)
+		if err != nil {
+			return nil, err
+		}
+		series[currency_helpers.CustomTime{Time: date}] = map[currency_helpers.CurrencyCode]currency_helpers.Decimal{second: d}
+	}
+	return &currency_helpers.CurrencyTimelineRates{
+		Base:      base,
+		Rates:     series,
+		StartDate: currency_helpers.CustomTime{Time: start},
+		EndDate:   currency_helpers.CustomTime{Time: end},
+	}, nil
+}
+
+func decimal(t *testing.T, s string) currency_helpers.Decimal {
+	t.Helper()
+	d, err := currency_helpers.ParseDecimal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func testDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	url, ok := os.LookupEnv("TEST_DATABASE_URL")
+	if !ok {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sqlx.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	driver, err := postgres.WithInstance(db.DB, &postgres.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrate.NewWithDatabaseInstance("file://../../migrations", "postgres", driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.Up()
+	if err != nil && err != migrate.ErrNoChange {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+// newTracker returns a tracker without the evaluation loop and a model name of the test,
+// whose predictions are removed after it.
+func newTracker(t *testing.T, provider rates.RateProvider) (*Tracker, string) {
+	t.Helper()
+	db := testDB(t)
+	model := "test:" + t.Name()
+
+	t.Cleanup(func() {
+		_, err := db.ExecContext(context.Background(), "delete from predictions where model = $1;", model)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	return InitTracker(db, provider, &config.Config{DBTimeout: 5 * time.Second}), model
+}
+
+// forecast predicts the rates for the consecutive days from first.
+func forecast(t *testing.T, model string, first time.Time, predicted ...string) *currency_helpers.Forecast {
+	t.Helper()
+	result := &currency_helpers.Forecast{Model: model, ModelVersion: "1", GeneratedAt: time.Now()}
+	for i, rate := range predicted {
+		result.Points = append(result.Points, currency_helpers.PredictedRate{
+			Date: currency_helpers.CustomTime{Time: first.AddDate(0, 0, i)},
+			Rate: decimal(t, rate),
+		})
+	}
+	return result
+}
+
+type storedPrediction struct {
+	TargetDate time.Time                 `db:"target_date"`
+	Horizon    int                       `db:"horizon"`
+	Rate       currency_helpers.Decimal  `db:"rate"`
+	Actual     *currency_helpers.Decimal `db:"actual"`
+	Evaluated  bool                      `db:"evaluated"`
+}
+
+func stored(t *testing.T, tracker *Tracker, model string) []storedPrediction {
+	t.Helper()
+	result := make([]storedPrediction, 0)
+	err := tracker.db.SelectContext(context.Background(), &result, `
+		select target_date, horizon, rate, actual, evaluated_at is not null as evaluated
+		from predictions where model = $1 order by target_date;
+	`, model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func today() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
+
+func TestRecord(t *testing.T) {
+	tracker, model := newTracker(t, nil)
+	ctx := context.Background()
+	first := today().AddDate(0, 0, 1)
+
+	err := tracker.Record(ctx, "USD", "EUR", forecast(t, model, first, "0.91", "0.92", "0.93"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a later forecast of the same day is dropped, one from another day is kept
+	err = tracker.Record(ctx, "USD", "EUR", forecast(t, model, first, "0.81", "0.82", "0.83"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tracker.Record(ctx, "USD", "EUR", forecast(t, model, first.AddDate(0, 0, 1), "0.95"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tracker.Record(ctx, "USD", "EUR", forecast(t, model, first))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := stored(t, tracker, model)
+	want := []struct {
+		day     int
+		horizon int
+		rate    string
+	}{
+		{0, 1, "0.91"},
+		{1, 2, "0.92"},
+		{1, 1, "0.95"},
+		{2, 3, "0.93"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d predictions %+v, want %d", len(got), got, len(want))
+	}
+	for _, w := range want {
+		found := false
+		for _, p := range got {
+			if p.TargetDate.Equal(first.AddDate(0, 0, w.day)) && p.Horizon == w.horizon {
+				found = true
+				if !p.Rate.Equal(decimal(t, w.rate)) {
+					t.Errorf("day %d horizon %d: got rate %s, want %s", w.day, w.horizon, p.Rate, w.rate)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("day %d horizon %d is missing from %+v", w.day, w.horizon, got)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	realizedDay := today().AddDate(0, 0, -3)
+	tracker, model := newTracker(t, &fakeProvider{realized: map[time.Time]string{realizedDay: "0.9"}})
+	ctx := context.Background()
+
+	// a week and more without a rate is expired, a few days without one still waits for it,
+	// and today's rate is not published yet
+	err := tracker.Record(ctx, "USD", "EUR", forecast(t, model, today().AddDate(0, 0, -10), "0.91"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tracker.Record(ctx, "USD", "EUR", forecast(t, model, realizedDay, "0.92", "0.93", "0.94", "0.95"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tracker.Evaluate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		day       int
+		actual    string
+		evaluated bool
+	}{
+		{-10, "", true},
+		{-3, "0.9", true},
+		{-2, "", false},
+		{-1, "", false},
+		{0, "", false},
+	}
+	got := stored(t, tracker, model)
+	if len(got) != len(want) {
+		t.Fatalf("got %d predictions %+v, want %d", len(got), got, len(want))
+	}
+	for i, w := range want {
+		p := got[i]
+		if !p.TargetDate.Equal(today().AddDate(0, 0, w.day)) {
+			t.Errorf("got target date %s, want day %d", p.TargetDate, w.day)
+			continue
+		}
+		if p.Evaluated != w.evaluated {
+			t.Errorf("day %d: got evaluated %t, want %t", w.day, p.Evaluated, w.evaluated)
+		}
+		switch {
+		case w.actual == "" && p.Actual != nil:
+			t.Errorf("day %d: got actual %s, want none", w.day, p.Actual)
+		case w.actual != "" && (p.Actual == nil || !p.Actual.Equal(decimal(t, w.actual))):
+			t.Errorf("day %d: got actual %v, want %s", w.day, p.Actual, w.actual)
+		}
+	}
+}
+
+func TestReport(t *testing.T) {
+	tracker, model := newTracker(t, nil)
+	ctx := context.Background()
+	first := today().AddDate(0, 0, -5)
+
+	for i, predicted := range [][]string{{"1.1", "1.2"}, {"0.9", "0.8"}, {"2"}} {
+		err := tracker.Record(ctx, "USD", "EUR", forecast(t, model, first.AddDate(0, 0, i), predicted...))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the last day is not realized and is left out of the figures
+	_, err := tracker.db.ExecContext(ctx, `
+		update predictions set actual = 1, evaluated_at = now()
+		where model = $1 and target_date < $2;
+	`, model, first.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := tracker.Report(ctx, Filter{Base: "USD", Second: "EUR", Model: model})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Accuracy{
+		{Base: "USD", Second: "EUR", Model: model, Horizon: 1, Count: 2, MAE: decimal(t, "0.1"), MAPE: decimal(t, "10")},
+		{Base: "USD", Second: "EUR", Model: model, Horizon: 2, Count: 1, MAE: decimal(t, "0.2"), MAPE: decimal(t, "20")},
+	}
+	if len(report) != len(want) {
+		t.Fatalf("got report %+v, want %+v", report, want)
+	}
+	for i, w := range want {
+		got := report[i]
+		if got.Base != w.Base || got.Second != w.Second || got.Model != w.Model ||
+			got.Horizon != w.Horizon || got.Count != w.Count || !got.MAE.Equal(w.MAE) || !got.MAPE.Equal(w.MAPE) {
+			t.Errorf("got %+v, want %+v", got, w)
+		}
+	}
+
+	report, err = tracker.Report(ctx, Filter{Base: "EUR", Model: model})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 0 {
+		t.Errorf("got report %+v of another pair", report)
+	}
+}
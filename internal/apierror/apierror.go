@@ -0,0 +1,102 @@
+package apierror
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Code is a stable machine readable error identifier, clients branch on it
+// instead of parsing messages, which may change.
+type Code string
+
+const (
+	CodeInvalidRequest Code = "invalid_request"
+	CodeBodyTooLarge   Code = "body_too_large"
+	CodeUnauthorized   Code = "unauthorized"
+	CodeForbidden      Code = "forbidden"
+	CodeNotFound       Code = "not_found"
+	CodeNotAcceptable  Code = "not_acceptable"
+	CodeCORSRejected   Code = "cors_rejected"
+	CodeInvalidAPIKey  Code = "invalid_api_key"
+	CodeAPIKeyNotFound Code = "api_key_not_found"
+	CodeRateLimited    Code = "rate_limited"
+	CodeQuotaExceeded  Code = "quota_exceeded"
+
+	CodeInvalidCurrency  Code = "invalid_currency"
+	CodeCurrencyNotFound Code = "currency_not_found"
+	CodeCurrencyExists   Code = "currency_exists"
+	CodeCurrencyBanned   Code = "currency_banned"
+	CodeInvalidBan       Code = "invalid_ban"
+	CodeRateNotFound     Code = "rate_not_found"
+
+	CodePredictionsDisabled   Code = "predictions_disabled"
+	CodePredictionJobNotFound Code = "prediction_job_not_found"
+
+	CodePairBlocked         Code = "pair_blocked"
+	CodeAmountExceeded      Code = "amount_exceeded"
+	CodeInvalidTradingRule  Code = "invalid_trading_rule"
+	CodeTradingRuleNotFound Code = "trading_rule_not_found"
+
+	CodeWalletNotFound    Code = "wallet_not_found"
+	CodeAccountNotFound   Code = "account_not_found"
+	CodeAccountExists     Code = "account_exists"
+	CodeInvalidAmount     Code = "invalid_amount"
+	CodeInsufficientFunds Code = "insufficient_funds"
+	CodeQuoteNotFound     Code = "quote_not_found"
+	CodeQuoteMismatch     Code = "quote_mismatch"
+	CodeQuoteUsed         Code = "quote_used"
+
+	CodeProviderUnavailable Code = "provider_unavailable"
+	CodeCacheFailure        Code = "cache_failure"
+	CodeInternal            Code = "internal_error"
+)
+
+// Error is the body of every error response. Details carry structured context,
+// like the per-item report of a rejected bulk request.
+type Error struct {
+	Status    int         `json:"-"`
+	Code      Code        `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"requestId,omitempty"`
+}
+
+func New(status int, code Code, message string) *Error {
+	return &Error{
+		Status:  status,
+		Code:    code,
+		Message: message,
+	}
+}
+
+func Errorf(status int, code Code, format string, args ...interface{}) *Error {
+	return New(status, code, fmt.Sprintf(format, args...))
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) WithDetails(details interface{}) *Error {
+	result := *e
+	result.Details = details
+	return &result
+}
+
+// Write renders the error as JSON, tagged with the id the RequestID middleware gave the request.
+func Write(w http.ResponseWriter, r *http.Request, e *Error) {
+	result := *e
+	result.RequestID = middleware.GetReqID(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(result.Status)
+	err := json.NewEncoder(w).Encode(result)
+	if err != nil {
+		log.Printf("error in marshalling error response: %s", err.Error())
+	}
+}
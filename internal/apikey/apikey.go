@@ -0,0 +1,213 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"wallet-service/internal/config"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// keyPrefix marks wallet service keys, so they are easy to spot in configs and logs.
+const keyPrefix = "wsk_"
+
+const keyColumns = `id, name, prefix, key_hash, rate_limit, daily_quota, created_at, revoked_at`
+
+var (
+	ErrKeyNotFound = errors.New("api key not found")
+	ErrInvalidKey  = errors.New("invalid api key")
+)
+
+// Key is an issued api key. Only the hash of the key is stored,
+// Prefix is the beginning of the key, kept to tell keys apart.
+type Key struct {
+	ID         int64      `json:"id" db:"id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	Hash       string     `json:"-" db:"key_hash"`
+	RateLimit  int        `json:"rateLimit" db:"rate_limit"`
+	DailyQuota int        `json:"dailyQuota" db:"daily_quota"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty" db:"revoked_at"`
+}
+
+// Issue holds the parameters of a new key, zero limits are replaced with the configured defaults.
+type Issue struct {
+	Name       string `json:"name"`
+	RateLimit  int    `json:"rateLimit"`
+	DailyQuota int    `json:"dailyQuota"`
+}
+
+// Issued is a newly created key together with the raw key,
+// which is not stored and cannot be shown again.
+type Issued struct {
+	Key
+	Raw string `json:"key"`
+}
+
+// Store keeps api keys in Postgres and authenticates requests against
+// an in-memory snapshot of the active keys, which is replaced after every change
+// made through it and periodically reloaded to pick up revocations made by other instances.
+type Store struct {
+	db  *sqlx.DB
+	cfg *config.Config
+
+	mu   sync.RWMutex
+	keys map[string]Key
+}
+
+func InitStore(db *sqlx.DB, cfg *config.Config) (*Store, error) {
+	s := &Store{
+		db:  db,
+		cfg: cfg,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DBTimeout)
+	defer cancel()
+	err := s.Refresh(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "load api keys")
+	}
+
+	if cfg.APIKeysRefreshInterval > 0 {
+		go s.refreshLoop(cfg.APIKeysRefreshInterval, cfg.DBTimeout)
+	}
+
+	return s, nil
+}
+
+func (s *Store) refreshLoop(interval, timeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := s.Refresh(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("error in refresh api keys: %s", err.Error())
+		}
+	}
+}
+
+func (s *Store) Refresh(ctx context.Context) error {
+	keys := make([]Key, 0)
+	err := s.db.SelectContext(ctx, &keys, "select "+keyColumns+" from api_keys where revoked_at is null;")
+	if err != nil {
+		return errors.Wrap(err, "select api keys")
+	}
+
+	snapshot := make(map[string]Key, len(keys))
+	for _, key := range keys {
+		snapshot[key.Hash] = key
+	}
+	s.mu.Lock()
+	s.keys = snapshot
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Authenticate returns the active key matching the raw key sent by the client.
+func (s *Store) Authenticate(raw string) (Key, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[hash(raw)]
+	return key, ok
+}
+
+// List returns all issued keys including revoked ones.
+func (s *Store) List(ctx context.Context) ([]Key, error) {
+	keys := make([]Key, 0)
+	err := s.db.SelectContext(ctx, &keys, "select "+keyColumns+" from api_keys order by id;")
+	if err != nil {
+		return nil, errors.Wrap(err, "select api keys")
+	}
+
+	return keys, nil
+}
+
+// Issue creates a key, the raw key is only returned from here.
+func (s *Store) Issue(ctx context.Context, issue Issue) (*Issued, error) {
+	issue.Name = strings.TrimSpace(issue.Name)
+	if issue.Name == "" {
+		return nil, errors.Wrap(ErrInvalidKey, "empty name")
+	}
+	if issue.RateLimit < 0 || issue.DailyQuota < 0 {
+		return nil, errors.Wrap(ErrInvalidKey, "limits must be positive")
+	}
+	if issue.RateLimit == 0 {
+		issue.RateLimit = s.cfg.APIKeyRateLimit
+	}
+	if issue.DailyQuota == 0 {
+		issue.DailyQuota = s.cfg.APIKeyDailyQuota
+	}
+
+	raw, err := generate()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		insert into api_keys (name, prefix, key_hash, rate_limit, daily_quota)
+		values ($1, $2, $3, $4, $5)
+		returning ` + keyColumns + `;`
+	key := &Key{}
+	err = s.db.QueryRowxContext(
+		ctx, query, issue.Name, raw[:len(keyPrefix)+8], hash(raw), issue.RateLimit, issue.DailyQuota,
+	).StructScan(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "insert api key")
+	}
+
+	s.mu.Lock()
+	s.keys[key.Hash] = *key
+	s.mu.Unlock()
+
+	return &Issued{Key: *key, Raw: raw}, nil
+}
+
+// Revoke stops the key from being accepted, the key record is kept.
+func (s *Store) Revoke(ctx context.Context, id int64) (*Key, error) {
+	query := `
+		update api_keys
+		set revoked_at = coalesce(revoked_at, now())
+		where id = $1
+		returning ` + keyColumns + `;`
+	key := &Key{}
+	err := s.db.QueryRowxContext(ctx, query, id).StructScan(key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.Wrapf(ErrKeyNotFound, "%d", id)
+		}
+		return nil, errors.Wrap(err, "revoke api key")
+	}
+
+	s.mu.Lock()
+	delete(s.keys, key.Hash)
+	s.mu.Unlock()
+
+	return key, nil
+}
+
+func generate() (string, error) {
+	data := make([]byte, 32)
+	_, err := rand.Read(data)
+	if err != nil {
+		return "", errors.Wrap(err, "generate api key")
+	}
+	return keyPrefix + hex.EncodeToString(data), nil
+}
+
+func hash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,100 @@
+package apikey
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrRateLimited          = errors.New("api key rate limit exceeded")
+	ErrQuotaExceeded        = errors.New("api key daily quota exceeded")
+	ErrAnonymousRateLimited = errors.New("rate limit of requests without an api key exceeded")
+)
+
+// Counter counts requests in a window ending at resetAt, see cache.Cache.
+type Counter interface {
+	CountRequest(ctx context.Context, key string, resetAt time.Time) (int64, error)
+}
+
+// Usage is the state of the limit a request was counted against.
+type Usage struct {
+	Limit     int
+	Remaining int
+	ResetAt   time.Time
+}
+
+// Limit counts the request against the per-minute rate limit and then against the daily quota
+// of the key. Requests rejected by the rate limit do not use up the quota.
+// On ErrRateLimited or ErrQuotaExceeded the usage tells when the exceeded limit resets.
+func Limit(ctx context.Context, counter Counter, key Key, now time.Time) (Usage, error) {
+	now = now.UTC()
+
+	minute := now.Truncate(time.Minute)
+	rate, err := count(
+		ctx, counter,
+		fmt.Sprintf("%s:%d:%d", currency_helpers.APIKeyRateCollection, key.ID, minute.Unix()),
+		key.RateLimit, minute.Add(time.Minute),
+	)
+	if err != nil {
+		return rate, err
+	}
+	if rate.Remaining < 0 {
+		return rate, ErrRateLimited
+	}
+
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	quota, err := count(
+		ctx, counter,
+		fmt.Sprintf("%s:%d:%s", currency_helpers.APIKeyQuotaCollection, key.ID, day.Format("2006-01-02")),
+		key.DailyQuota, day.AddDate(0, 0, 1),
+	)
+	if err != nil {
+		return quota, err
+	}
+	if quota.Remaining < 0 {
+		return quota, ErrQuotaExceeded
+	}
+
+	// the tighter of the two limits is reported
+	if quota.Remaining < rate.Remaining {
+		return quota, nil
+	}
+	return rate, nil
+}
+
+// LimitAnonymous counts a request made without a key against the per-minute rate limit of its address.
+func LimitAnonymous(ctx context.Context, counter Counter, address string, limit int, now time.Time) (Usage, error) {
+	minute := now.UTC().Truncate(time.Minute)
+	rate, err := count(
+		ctx, counter,
+		fmt.Sprintf("%s:%s:%d", currency_helpers.AnonymousRateCollection, address, minute.Unix()),
+		limit, minute.Add(time.Minute),
+	)
+	if err != nil {
+		return rate, err
+	}
+	if rate.Remaining < 0 {
+		return rate, ErrAnonymousRateLimited
+	}
+
+	return rate, nil
+}
+
+func count(ctx context.Context, counter Counter, key string, limit int, resetAt time.Time) (Usage, error) {
+	usage := Usage{
+		Limit:   limit,
+		ResetAt: resetAt,
+	}
+
+	used, err := counter.CountRequest(ctx, key, resetAt)
+	if err != nil {
+		return usage, err
+	}
+	usage.Remaining = limit - int(used)
+
+	return usage, nil
+}
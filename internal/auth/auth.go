@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"os"
+	"strings"
+	"wallet-service/internal/config"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+)
+
+type Role string
+
+const (
+	RoleAdmin Role = "admin"
+	RoleUser  Role = "user"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// Principal is the authenticated caller, Subject is the user id wallets belong to.
+type Principal struct {
+	Subject string `json:"subject"`
+	Roles   []Role `json:"roles"`
+}
+
+func (p *Principal) HasRole(roles ...Role) bool {
+	for _, has := range p.Roles {
+		for _, role := range roles {
+			if has == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *Principal) IsAdmin() bool {
+	return p.HasRole(RoleAdmin)
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the principal of the request, false for anonymous requests.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+type claims struct {
+	Roles []Role `json:"roles"`
+	jwt.RegisteredClaims
+}
+
+// Verifier checks JWT signatures offline with a shared HMAC secret or an RSA public key.
+type Verifier struct {
+	key      interface{}
+	method   string
+	issuer   string
+	audience string
+}
+
+func InitVerifier(cfg *config.Config) (*Verifier, error) {
+	v := &Verifier{
+		method:   cfg.JWTAlgorithm,
+		issuer:   cfg.JWTIssuer,
+		audience: cfg.JWTAudience,
+	}
+
+	switch jwt.GetSigningMethod(cfg.JWTAlgorithm).(type) {
+	case *jwt.SigningMethodHMAC:
+		if cfg.JWTSecret == "" {
+			return nil, errors.Errorf("JWT_SECRET is required for %s", cfg.JWTAlgorithm)
+		}
+		v.key = []byte(cfg.JWTSecret)
+	case *jwt.SigningMethodRSA:
+		key, err := readRSAPublicKey(cfg.JWTPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		v.key = key
+	default:
+		return nil, errors.Errorf("unsupported jwt algorithm '%s'", cfg.JWTAlgorithm)
+	}
+
+	return v, nil
+}
+
+func readRSAPublicKey(path string) (*rsa.PublicKey, error) {
+	if path == "" {
+		return nil, errors.New("JWT_PUBLIC_KEY_FILE is required for RSA algorithms")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read jwt public key")
+	}
+	key, err := jwt.ParseRSAPublicKeyFromPEM(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse jwt public key")
+	}
+
+	return key, nil
+}
+
+// Verify parses the token, checking its signature, expiry and, if configured, issuer and audience.
+func (v *Verifier) Verify(token string) (*Principal, error) {
+	parsed := &claims{}
+	_, err := jwt.ParseWithClaims(token, parsed, func(*jwt.Token) (interface{}, error) {
+		return v.key, nil
+	}, jwt.WithValidMethods([]string{v.method}))
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidToken, err.Error())
+	}
+
+	if parsed.ExpiresAt == nil {
+		return nil, errors.Wrap(ErrInvalidToken, "token has no expiry")
+	}
+	if parsed.Subject == "" {
+		return nil, errors.Wrap(ErrInvalidToken, "token has no subject")
+	}
+	if v.issuer != "" && !parsed.VerifyIssuer(v.issuer, true) {
+		return nil, errors.Wrap(ErrInvalidToken, "unexpected issuer")
+	}
+	if v.audience != "" && !parsed.VerifyAudience(v.audience, true) {
+		return nil, errors.Wrap(ErrInvalidToken, "unexpected audience")
+	}
+
+	return &Principal{
+		Subject: parsed.Subject,
+		Roles:   parsed.Roles,
+	}, nil
+}
+
+// BearerToken extracts the token from the Authorization header value.
+func BearerToken(header string) (string, bool) {
+	const prefix = "bearer "
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", false
+	}
+	return strings.TrimSpace(header[len(prefix):]), true
+}
@@ -6,13 +6,19 @@ import (
 	"fmt"
 	"github.com/go-redis/redis/v9"
 	"github.com/pkg/errors"
+	"time"
 	"wallet-service/internal/config"
 	"wallet-service/internal/currency_helpers"
 )
 
 type Cache interface {
 	GetAvailableCurrencies(ctx context.Context) ([]currency_helpers.CurrencyWithBanStatus, error)
-	SetAvailableCurrencies(ctx context.Context, availableCurrencies []currency_helpers.CurrencyWithBanStatus) error
+	// SetAvailableCurrencies saves the list until expiration passes, zero expiration keeps it until cleaned.
+	SetAvailableCurrencies(
+		ctx context.Context,
+		availableCurrencies []currency_helpers.CurrencyWithBanStatus,
+		expiration time.Duration,
+	) error
 	CleanCacheForAvailableCurrencies(ctx context.Context) error
 
 	GetCurrencyLastRate(
@@ -20,14 +26,40 @@ type Cache interface {
 		currencyCodeBase currency_helpers.CurrencyCode,
 		currencyCodeSecond currency_helpers.CurrencyCode,
 	) (*currency_helpers.CurrencyRate, error)
-	SetCurrencyLastRate(ctx context.Context, currencyRates *currency_helpers.CurrencyRates) error
+	// SetCurrencyLastRate keeps the rates of the base until expiresAt.
+	SetCurrencyLastRate(ctx context.Context, currencyRates *currency_helpers.CurrencyRates, expiresAt time.Time) error
 
 	GetTimestampRate(
 		ctx context.Context,
 		currencyCodeBase currency_helpers.CurrencyCode,
 		currencyCodeSecond currency_helpers.CurrencyCode,
 	) (*currency_helpers.CurrencyTimelineRate, error)
-	SaveTimestampRate(ctx context.Context, rate *currency_helpers.CurrencyTimelineRate) error
+	// SaveTimestampRate keeps the time series of the pair until expiresAt.
+	SaveTimestampRate(ctx context.Context, rate *currency_helpers.CurrencyTimelineRate, expiresAt time.Time) error
+	GetForecast(
+		ctx context.Context,
+		currencyCodeBase currency_helpers.CurrencyCode,
+		currencyCodeSecond currency_helpers.CurrencyCode,
+		model string,
+	) (*currency_helpers.Forecast, error)
+	// SaveForecast keeps the forecast of the model for the pair until expiresAt.
+	SaveForecast(
+		ctx context.Context,
+		currencyCodeBase currency_helpers.CurrencyCode,
+		currencyCodeSecond currency_helpers.CurrencyCode,
+		forecast *currency_helpers.Forecast,
+		expiresAt time.Time,
+	) error
+
+	SaveQuote(ctx context.Context, quote *currency_helpers.Quote) error
+	GetQuote(ctx context.Context, id string) (*currency_helpers.Quote, error)
+	// UseQuote marks the quote as used and reports false if it was already used.
+	UseQuote(ctx context.Context, quote *currency_helpers.Quote) (bool, error)
+	ReleaseQuote(ctx context.Context, id string) error
+
+	// CountRequest increments the counter under key and returns its new value,
+	// the counter is dropped at resetAt.
+	CountRequest(ctx context.Context, key string, resetAt time.Time) (int64, error)
 }
 
 func InitCache(cfg *config.Config) (Cache, error) {
@@ -70,12 +102,16 @@ func (r *Redis) GetAvailableCurrencies(ctx context.Context) ([]currency_helpers.
 	return result, nil
 }
 
-func (r *Redis) SetAvailableCurrencies(ctx context.Context, availableCurrencies []currency_helpers.CurrencyWithBanStatus) error {
+func (r *Redis) SetAvailableCurrencies(
+	ctx context.Context,
+	availableCurrencies []currency_helpers.CurrencyWithBanStatus,
+	expiration time.Duration,
+) error {
 	data, err := json.Marshal(availableCurrencies)
 	if err != nil {
 		return errors.Wrap(err, "error in marshal data for redis")
 	}
-	saved, err := r.rds.Set(ctx, currency_helpers.AvailableCurrencies, string(data), 0).Result()
+	saved, err := r.rds.Set(ctx, currency_helpers.AvailableCurrencies, string(data), expiration).Result()
 	if err != nil {
 		return errors.Wrap(err, "save available currencies")
 	}
@@ -125,7 +161,11 @@ func (r *Redis) GetCurrencyLastRate(
 	return result.ToResultRate(currencyCodeSecond), nil
 }
 
-func (r *Redis) SetCurrencyLastRate(ctx context.Context, currencyRates *currency_helpers.CurrencyRates) error {
+func (r *Redis) SetCurrencyLastRate(
+	ctx context.Context,
+	currencyRates *currency_helpers.CurrencyRates,
+	expiresAt time.Time,
+) error {
 	data, err := json.Marshal(currencyRates)
 	if err != nil {
 		return errors.Wrap(err, "error in marshal data for redis")
@@ -134,7 +174,7 @@ func (r *Redis) SetCurrencyLastRate(ctx context.Context, currencyRates *currency
 		ctx,
 		fmt.Sprintf("%s:%s", currency_helpers.CurrentTimeRateCollection, currencyRates.Base.String()),
 		string(data),
-		0,
+		time.Until(expiresAt),
 	).Result()
 	if err != nil {
 		return errors.Wrap(err, "save currency last rate")
@@ -178,7 +218,11 @@ func (r *Redis) GetTimestampRate(
 	return &result, nil
 }
 
-func (r *Redis) SaveTimestampRate(ctx context.Context, rate *currency_helpers.CurrencyTimelineRate) error {
+func (r *Redis) SaveTimestampRate(
+	ctx context.Context,
+	rate *currency_helpers.CurrencyTimelineRate,
+	expiresAt time.Time,
+) error {
 	data, err := json.Marshal(rate)
 	if err != nil {
 		return errors.Wrap(err, "error in marshal data for redis")
@@ -191,7 +235,7 @@ func (r *Redis) SaveTimestampRate(ctx context.Context, rate *currency_helpers.Cu
 			rate.Base.String(),
 			rate.Second.String()),
 		string(data),
-		0,
+		time.Until(expiresAt),
 	).Result()
 	if err != nil {
 		return errors.Wrap(err, "save currency last rate")
@@ -203,3 +247,151 @@ func (r *Redis) SaveTimestampRate(ctx context.Context, rate *currency_helpers.Cu
 
 	return nil
 }
+
+func (r *Redis) GetForecast(
+	ctx context.Context,
+	currencyCodeBase currency_helpers.CurrencyCode,
+	currencyCodeSecond currency_helpers.CurrencyCode,
+	model string,
+) (*currency_helpers.Forecast, error) {
+	jsonData, err := r.rds.Get(ctx, forecastKey(currencyCodeBase, currencyCodeSecond, model)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+
+		return nil, errors.Wrap(err, "get forecast error")
+	}
+
+	var result currency_helpers.Forecast
+	err = json.Unmarshal([]byte(jsonData), &result)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse forecast data")
+	}
+
+	return &result, nil
+}
+
+func (r *Redis) SaveForecast(
+	ctx context.Context,
+	currencyCodeBase currency_helpers.CurrencyCode,
+	currencyCodeSecond currency_helpers.CurrencyCode,
+	forecast *currency_helpers.Forecast,
+	expiresAt time.Time,
+) error {
+	data, err := json.Marshal(forecast)
+	if err != nil {
+		return errors.Wrap(err, "error in marshal data for redis")
+	}
+	status, err := r.rds.Set(
+		ctx,
+		forecastKey(currencyCodeBase, currencyCodeSecond, forecast.Model),
+		string(data),
+		time.Until(expiresAt),
+	).Result()
+	if err != nil {
+		return errors.Wrap(err, "save forecast")
+	}
+
+	if status != "OK" {
+		return errors.New("save no info")
+	}
+
+	return nil
+}
+
+func forecastKey(
+	currencyCodeBase currency_helpers.CurrencyCode,
+	currencyCodeSecond currency_helpers.CurrencyCode,
+	model string,
+) string {
+	return fmt.Sprintf(
+		"%s:%s:%s:%s",
+		currency_helpers.PredictionCollection,
+		currencyCodeBase.String(),
+		currencyCodeSecond.String(),
+		model,
+	)
+}
+
+func (r *Redis) SaveQuote(ctx context.Context, quote *currency_helpers.Quote) error {
+	data, err := json.Marshal(quote)
+	if err != nil {
+		return errors.Wrap(err, "error in marshal data for redis")
+	}
+	status, err := r.rds.Set(
+		ctx,
+		fmt.Sprintf("%s:%s", currency_helpers.QuoteCollection, quote.ID),
+		string(data),
+		time.Until(quote.ExpiresAt),
+	).Result()
+	if err != nil {
+		return errors.Wrap(err, "save quote")
+	}
+
+	if status != "OK" {
+		return errors.New("save no info")
+	}
+
+	return nil
+}
+
+func (r *Redis) GetQuote(ctx context.Context, id string) (*currency_helpers.Quote, error) {
+	jsonData, err := r.rds.Get(ctx, fmt.Sprintf("%s:%s", currency_helpers.QuoteCollection, id)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+
+		return nil, errors.Wrap(err, "get quote error")
+	}
+
+	var result currency_helpers.Quote
+	err = json.Unmarshal([]byte(jsonData), &result)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse quote data")
+	}
+
+	return &result, nil
+}
+
+func (r *Redis) UseQuote(ctx context.Context, quote *currency_helpers.Quote) (bool, error) {
+	ttl := time.Until(quote.ExpiresAt)
+	if ttl <= 0 {
+		return false, nil
+	}
+
+	// the used mark outlives the quote itself, so the quote cannot be used twice
+	used, err := r.rds.SetNX(
+		ctx,
+		fmt.Sprintf("%s:%s", currency_helpers.UsedQuoteCollection, quote.ID),
+		time.Now().Format(time.RFC3339),
+		ttl+time.Minute,
+	).Result()
+	if err != nil {
+		return false, errors.Wrap(err, "mark quote used")
+	}
+
+	return used, nil
+}
+
+func (r *Redis) ReleaseQuote(ctx context.Context, id string) error {
+	_, err := r.rds.Del(ctx, fmt.Sprintf("%s:%s", currency_helpers.UsedQuoteCollection, id)).Result()
+	if err != nil {
+		return errors.Wrap(err, "release quote")
+	}
+
+	return nil
+}
+
+func (r *Redis) CountRequest(ctx context.Context, key string, resetAt time.Time) (int64, error) {
+	pipe := r.rds.TxPipeline()
+	count := pipe.Incr(ctx, key)
+	pipe.ExpireAt(ctx, key, resetAt)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "count request")
+	}
+
+	return count.Val(), nil
+}
@@ -0,0 +1,277 @@
+package catalogue
+
+import (
+	"context"
+	"time"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidBan = errors.New("invalid ban")
+
+// BanWindow forbids operations in the currency from StartsAt until EndsAt.
+// A nil EndsAt means the ban lasts until it is lifted.
+type BanWindow struct {
+	ID        int64                         `json:"id" db:"id"`
+	Currency  currency_helpers.CurrencyCode `json:"currency" db:"currency"`
+	Reason    string                        `json:"reason" db:"reason"`
+	Author    string                        `json:"author" db:"author"`
+	StartsAt  time.Time                     `json:"startsAt" db:"starts_at"`
+	EndsAt    *time.Time                    `json:"endsAt,omitempty" db:"ends_at"`
+	LiftedAt  *time.Time                    `json:"liftedAt,omitempty" db:"lifted_at"`
+	LiftedBy  string                        `json:"liftedBy,omitempty" db:"lifted_by"`
+	CreatedAt time.Time                     `json:"createdAt" db:"created_at"`
+}
+
+func (b BanWindow) ActiveAt(t time.Time) bool {
+	return b.LiftedAt == nil && !t.Before(b.StartsAt) && (b.EndsAt == nil || t.Before(*b.EndsAt))
+}
+
+// BanChange opens a ban window for the currency when Banned is set,
+// otherwise it lifts the active and scheduled windows of the currency.
+// A zero StartsAt starts the ban now, a nil EndsAt keeps it until lifted.
+type BanChange struct {
+	Currency currency_helpers.CurrencyCode `json:"currency"`
+	Banned   bool                          `json:"banned"`
+	Reason   string                        `json:"reason,omitempty"`
+	Author   string                        `json:"author"`
+	StartsAt time.Time                     `json:"start"`
+	EndsAt   *time.Time                    `json:"end,omitempty"`
+}
+
+// BanChangeResult holds the window opened or the windows lifted by the change.
+type BanChangeResult struct {
+	Currency currency_helpers.CurrencyCode `json:"currency"`
+	Banned   bool                          `json:"banned"`
+	Window   *BanWindow                    `json:"window,omitempty"`
+	Lifted   []BanWindow                   `json:"lifted,omitempty"`
+	Error    string                        `json:"error,omitempty"`
+}
+
+// ValidateBanChange checks the change against the catalogue.
+func (c *Catalogue) ValidateBanChange(change BanChange) error {
+	if _, ok := c.Get(change.Currency); !ok {
+		return errors.Wrapf(ErrCurrencyNotFound, "'%s'", change.Currency)
+	}
+	if change.Author == "" {
+		return errors.Wrap(ErrInvalidBan, "author is required")
+	}
+	if !change.Banned {
+		return nil
+	}
+
+	if change.Reason == "" {
+		return errors.Wrap(ErrInvalidBan, "reason is required")
+	}
+	now := time.Now()
+	startsAt := change.StartsAt
+	if startsAt.IsZero() {
+		startsAt = now
+	}
+	if change.EndsAt != nil && (!change.EndsAt.After(startsAt) || !change.EndsAt.After(now)) {
+		return errors.Wrap(ErrInvalidBan, "end must be after the start and in the future")
+	}
+
+	return nil
+}
+
+// ChangeBans applies all changes in one transaction, either every change is applied or none.
+func (c *Catalogue) ChangeBans(ctx context.Context, changes []BanChange) ([]BanChangeResult, error) {
+	for i, change := range changes {
+		err := c.ValidateBanChange(change)
+		if err != nil {
+			return nil, errors.Wrapf(err, "change %d", i)
+		}
+	}
+
+	tx, err := c.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	results := make([]BanChangeResult, 0, len(changes))
+	for _, change := range changes {
+		result := BanChangeResult{
+			Currency: change.Currency,
+			Banned:   change.Banned,
+		}
+		if change.Banned {
+			result.Window, err = ban(ctx, tx, change)
+		} else {
+			result.Lifted, err = lift(ctx, tx, change)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "'%s'", change.Currency)
+		}
+		results = append(results, result)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, errors.Wrap(err, "commit transaction")
+	}
+
+	c.mu.Lock()
+	if c.bans == nil {
+		c.bans = make(map[currency_helpers.CurrencyCode][]BanWindow)
+	}
+	for _, result := range results {
+		if result.Window != nil {
+			c.bans[result.Currency] = append(c.bans[result.Currency], *result.Window)
+		} else {
+			delete(c.bans, result.Currency)
+		}
+	}
+	c.mu.Unlock()
+
+	return results, nil
+}
+
+func ban(ctx context.Context, tx *sqlx.Tx, change BanChange) (*BanWindow, error) {
+	window := BanWindow{
+		Currency: change.Currency,
+		Reason:   change.Reason,
+		Author:   change.Author,
+		StartsAt: change.StartsAt,
+		EndsAt:   change.EndsAt,
+	}
+	if window.StartsAt.IsZero() {
+		window.StartsAt = time.Now()
+	}
+
+	query := `
+		insert into currency_ban_windows (currency, reason, author, starts_at, ends_at)
+		values (:currency, :reason, :author, :starts_at, :ends_at)
+		returning id, currency, reason, author, starts_at, ends_at, lifted_at, lifted_by, created_at;
+	`
+	query, params, err := tx.BindNamed(query, window)
+	if err != nil {
+		return nil, errors.Wrap(err, "prepare ban query")
+	}
+
+	result := &BanWindow{}
+	err = tx.QueryRowxContext(ctx, query, params...).StructScan(result)
+	if err != nil {
+		return nil, errors.Wrap(err, "insert ban window")
+	}
+
+	err = recordEvent(ctx, tx, BanEvent{
+		Currency: result.Currency,
+		Action:   BanActionBan,
+		WindowID: result.ID,
+		Reason:   result.Reason,
+		Author:   result.Author,
+		StartsAt: result.StartsAt,
+		EndsAt:   result.EndsAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func lift(ctx context.Context, tx *sqlx.Tx, change BanChange) ([]BanWindow, error) {
+	query := `
+		update currency_ban_windows
+		set lifted_at = now(), lifted_by = $2
+		where currency = $1
+			and lifted_at is null
+			and (ends_at is null or ends_at > now())
+		returning id, currency, reason, author, starts_at, ends_at, lifted_at, lifted_by, created_at;
+	`
+	lifted := make([]BanWindow, 0)
+	err := tx.SelectContext(ctx, &lifted, query, change.Currency, change.Author)
+	if err != nil {
+		return nil, errors.Wrap(err, "lift ban windows")
+	}
+
+	for _, window := range lifted {
+		err = recordEvent(ctx, tx, BanEvent{
+			Currency: window.Currency,
+			Action:   BanActionLift,
+			WindowID: window.ID,
+			Reason:   change.Reason,
+			Author:   change.Author,
+			StartsAt: window.StartsAt,
+			EndsAt:   window.EndsAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return lifted, nil
+}
+
+// NextBanChange returns the closest moment after now when a ban window opens or closes.
+func (c *Catalogue) NextBanChange(now time.Time) (time.Time, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var (
+		next  time.Time
+		found bool
+	)
+	consider := func(t time.Time) {
+		if t.After(now) && (!found || t.Before(next)) {
+			next, found = t, true
+		}
+	}
+	for _, windows := range c.bans {
+		for _, window := range windows {
+			consider(window.StartsAt)
+			if window.EndsAt != nil {
+				consider(*window.EndsAt)
+			}
+		}
+	}
+
+	return next, found
+}
+
+// loadBans selects the ban windows which are active or scheduled.
+func (c *Catalogue) loadBans(ctx context.Context) (map[currency_helpers.CurrencyCode][]BanWindow, error) {
+	query := `
+		select id, currency, reason, author, starts_at, ends_at, lifted_at, lifted_by, created_at
+		from currency_ban_windows
+		where lifted_at is null
+			and (ends_at is null or ends_at > now())
+		order by starts_at;
+	`
+	var windows []BanWindow
+	err := c.db.SelectContext(ctx, &windows, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "select ban windows")
+	}
+
+	bans := make(map[currency_helpers.CurrencyCode][]BanWindow)
+	for _, window := range windows {
+		bans[window.Currency] = append(bans[window.Currency], window)
+	}
+
+	return bans, nil
+}
+
+// withBan fills the ban status of the currency at the moment, c.mu must be held.
+// Of several active windows the one lasting longest is reported.
+func (c *Catalogue) withBan(currency Currency, now time.Time) Currency {
+	currency.Banned = false
+	currency.Ban = nil
+	for _, window := range c.bans[currency.Code] {
+		if !window.ActiveAt(now) {
+			continue
+		}
+		if currency.Ban == nil || currency.Ban.EndsAt != nil &&
+			(window.EndsAt == nil || window.EndsAt.After(*currency.Ban.EndsAt)) {
+			window := window
+			currency.Ban = &window
+		}
+	}
+	currency.Banned = currency.Ban != nil
+
+	return currency
+}
@@ -0,0 +1,340 @@
+package catalogue
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+	"wallet-service/internal/config"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// MaxMinorUnits is the precision of amounts stored in the ledger.
+const MaxMinorUnits int32 = 8
+
+var (
+	ErrCurrencyNotFound = errors.New("currency not found")
+	ErrCurrencyExists   = errors.New("currency already exists")
+	ErrInvalidCurrency  = errors.New("invalid currency")
+)
+
+var (
+	codePattern        = regexp.MustCompile(`^[A-Z]{3}$`)
+	numericCodePattern = regexp.MustCompile(`^[0-9]{3}$`)
+)
+
+// Currency is a catalogue entry. Disabled currencies keep their metadata
+// so existing balances can still be shown, but cannot be used in new operations.
+// Banned and Ban reflect the ban window active at the moment the entry was read.
+type Currency struct {
+	currency_helpers.CurrencyInfo
+	Enabled   bool       `json:"enabled" db:"enabled"`
+	Banned    bool       `json:"banned" db:"-"`
+	Ban       *BanWindow `json:"ban,omitempty" db:"-"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+func (c Currency) WithBanStatus() currency_helpers.CurrencyWithBanStatus {
+	info := c.CurrencyInfo
+	result := currency_helpers.CurrencyWithBanStatus{
+		Currency: c.Code,
+		Banned:   c.Banned,
+		Info:     &info,
+	}
+	if c.Ban != nil {
+		result.BanReason = c.Ban.Reason
+		result.BannedUntil = c.Ban.EndsAt
+	}
+	return result
+}
+
+// Description holds currency metadata to change, nil fields are left as is.
+// Minor units cannot be changed once balances may exist in the currency.
+type Description struct {
+	NumericCode *string `json:"numericCode"`
+	NameEn      *string `json:"nameEn"`
+	NameRu      *string `json:"nameRu"`
+	Symbol      *string `json:"symbol"`
+	Crypto      *bool   `json:"crypto"`
+	NonISO      *bool   `json:"nonIso"`
+}
+
+// Catalogue keeps the supported currencies in Postgres and serves lookups
+// from an in-memory snapshot, which is replaced after every change made through it
+// and periodically reloaded to pick up changes made by other instances.
+type Catalogue struct {
+	db *sqlx.DB
+
+	mu         sync.RWMutex
+	currencies map[currency_helpers.CurrencyCode]Currency
+	// bans holds current and scheduled ban windows, see withBan
+	bans map[currency_helpers.CurrencyCode][]BanWindow
+}
+
+func InitCatalogue(db *sqlx.DB, cfg *config.Config) (*Catalogue, error) {
+	c := &Catalogue{
+		db: db,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DBTimeout)
+	defer cancel()
+	err := c.Refresh(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "load currency catalogue")
+	}
+
+	if cfg.CatalogueRefreshInterval > 0 {
+		go c.refreshLoop(cfg.CatalogueRefreshInterval, cfg.DBTimeout)
+	}
+
+	return c, nil
+}
+
+func (c *Catalogue) refreshLoop(interval, timeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := c.Refresh(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("error in refresh currency catalogue: %s", err.Error())
+		}
+	}
+}
+
+// Refresh reloads the snapshot from the database.
+func (c *Catalogue) Refresh(ctx context.Context) error {
+	query := `
+		select code, numeric_code, name_en, name_ru, symbol, minor_units, crypto, non_iso,
+			enabled, created_at, updated_at
+		from currencies;
+	`
+	var currencies []Currency
+	err := c.db.SelectContext(ctx, &currencies, query)
+	if err != nil {
+		return errors.Wrap(err, "select currencies")
+	}
+
+	bans, err := c.loadBans(ctx)
+	if err != nil {
+		return err
+	}
+
+	snapshot := make(map[currency_helpers.CurrencyCode]Currency, len(currencies))
+	for _, currency := range currencies {
+		snapshot[currency.Code] = currency
+	}
+	c.mu.Lock()
+	c.currencies = snapshot
+	c.bans = bans
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Get returns the currency, whether enabled or not.
+func (c *Catalogue) Get(code currency_helpers.CurrencyCode) (Currency, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	currency, ok := c.currencies[code]
+	if !ok {
+		return Currency{}, false
+	}
+	return c.withBan(currency, time.Now()), true
+}
+
+// Supported reports whether the currency is in the catalogue and enabled.
+func (c *Catalogue) Supported(code currency_helpers.CurrencyCode) bool {
+	currency, ok := c.Get(code)
+	return ok && currency.Enabled
+}
+
+func (c *Catalogue) IsBanned(code currency_helpers.CurrencyCode) bool {
+	currency, ok := c.Get(code)
+	return ok && currency.Banned
+}
+
+// MinorUnits returns the number of decimal places amounts in the currency are kept with.
+// Disabled currencies keep theirs for existing balances, unknown ones get the ledger precision.
+func (c *Catalogue) MinorUnits(code currency_helpers.CurrencyCode) int32 {
+	currency, ok := c.Get(code)
+	if !ok {
+		return MaxMinorUnits
+	}
+	return currency.MinorUnits
+}
+
+// Money is the amount in the currency with its minor units.
+func (c *Catalogue) Money(amount currency_helpers.Decimal, code currency_helpers.CurrencyCode) currency_helpers.Money {
+	return currency_helpers.NewMoney(amount, code, c.MinorUnits(code))
+}
+
+// List returns all currencies sorted by code.
+func (c *Catalogue) List() []Currency {
+	now := time.Now()
+
+	c.mu.RLock()
+	result := make([]Currency, 0, len(c.currencies))
+	for _, currency := range c.currencies {
+		result = append(result, c.withBan(currency, now))
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Code < result[j].Code
+	})
+
+	return result
+}
+
+func (c *Catalogue) Add(ctx context.Context, info currency_helpers.CurrencyInfo) (*Currency, error) {
+	err := validate(info)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		insert into currencies (code, numeric_code, name_en, name_ru, symbol, minor_units, crypto, non_iso)
+		values (:code, :numeric_code, :name_en, :name_ru, :symbol, :minor_units, :crypto, :non_iso)
+		returning code, numeric_code, name_en, name_ru, symbol, minor_units, crypto, non_iso,
+			enabled, created_at, updated_at;
+	`
+	query, params, err := c.db.BindNamed(query, info)
+	if err != nil {
+		return nil, errors.Wrap(err, "prepare currency query")
+	}
+
+	currency := &Currency{}
+	err = c.db.QueryRowxContext(ctx, query, params...).StructScan(currency)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return nil, errors.Wrapf(ErrCurrencyExists, "'%s'", info.Code)
+		}
+		return nil, errors.Wrap(err, "insert currency")
+	}
+	c.store(*currency)
+
+	result, _ := c.Get(currency.Code)
+	return &result, nil
+}
+
+func (c *Catalogue) Describe(
+	ctx context.Context,
+	code currency_helpers.CurrencyCode,
+	description Description,
+) (*Currency, error) {
+	current, ok := c.Get(code)
+	if !ok {
+		return nil, errors.Wrapf(ErrCurrencyNotFound, "'%s'", code)
+	}
+
+	info := current.CurrencyInfo
+	if description.NumericCode != nil {
+		info.NumericCode = *description.NumericCode
+	}
+	if description.NameEn != nil {
+		info.NameEn = *description.NameEn
+	}
+	if description.NameRu != nil {
+		info.NameRu = *description.NameRu
+	}
+	if description.Symbol != nil {
+		info.Symbol = *description.Symbol
+	}
+	if description.Crypto != nil {
+		info.Crypto = *description.Crypto
+	}
+	if description.NonISO != nil {
+		info.NonISO = *description.NonISO
+	}
+	err := validate(info)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		update currencies
+		set numeric_code = :numeric_code, name_en = :name_en, name_ru = :name_ru, symbol = :symbol,
+			crypto = :crypto, non_iso = :non_iso, updated_at = now()
+		where code = :code
+		returning code, numeric_code, name_en, name_ru, symbol, minor_units, crypto, non_iso,
+			enabled, created_at, updated_at;
+	`
+	query, params, err := c.db.BindNamed(query, info)
+	if err != nil {
+		return nil, errors.Wrap(err, "prepare currency query")
+	}
+	return c.update(ctx, code, query, params...)
+}
+
+func (c *Catalogue) SetEnabled(ctx context.Context, code currency_helpers.CurrencyCode, enabled bool) (*Currency, error) {
+	query := `
+		update currencies
+		set enabled = $2, updated_at = now()
+		where code = $1
+		returning code, numeric_code, name_en, name_ru, symbol, minor_units, crypto, non_iso,
+			enabled, created_at, updated_at;
+	`
+	return c.update(ctx, code, query, code, enabled)
+}
+
+// update runs the query returning the changed currency row and stores it in the snapshot.
+func (c *Catalogue) update(
+	ctx context.Context,
+	code currency_helpers.CurrencyCode,
+	query string,
+	args ...interface{},
+) (*Currency, error) {
+	currency := &Currency{}
+	err := c.db.QueryRowxContext(ctx, query, args...).StructScan(currency)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.Wrapf(ErrCurrencyNotFound, "'%s'", code)
+		}
+		return nil, errors.Wrap(err, "update currency")
+	}
+	c.store(*currency)
+
+	result, _ := c.Get(code)
+	return &result, nil
+}
+
+// store puts the currency into a copy of the snapshot.
+func (c *Catalogue) store(currency Currency) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snapshot := make(map[currency_helpers.CurrencyCode]Currency, len(c.currencies)+1)
+	for code, v := range c.currencies {
+		snapshot[code] = v
+	}
+	snapshot[currency.Code] = currency
+	c.currencies = snapshot
+}
+
+func validate(info currency_helpers.CurrencyInfo) error {
+	if !codePattern.MatchString(info.Code.String()) {
+		return errors.Wrap(ErrInvalidCurrency, "code must be three capital latin letters")
+	}
+	if info.NumericCode != "" && !numericCodePattern.MatchString(info.NumericCode) {
+		return errors.Wrap(ErrInvalidCurrency, "numeric code must be three digits")
+	}
+	if info.NameEn == "" {
+		return errors.Wrap(ErrInvalidCurrency, "english name is required")
+	}
+	if info.MinorUnits < 0 || info.MinorUnits > MaxMinorUnits {
+		return errors.Wrapf(ErrInvalidCurrency, "minor units must be in [0, %d]", MaxMinorUnits)
+	}
+
+	return nil
+}
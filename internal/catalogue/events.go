@@ -0,0 +1,113 @@
+package catalogue
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+type BanAction string
+
+const (
+	BanActionBan  BanAction = "ban"
+	BanActionLift BanAction = "lift"
+)
+
+const (
+	DefaultBanEventsLimit = 100
+	MaxBanEventsLimit     = 1000
+)
+
+// BanEvent is an append-only record of a ban window being opened or lifted.
+// StartsAt and EndsAt are the bounds of the window at the moment of the event.
+type BanEvent struct {
+	ID        int64                         `json:"id" db:"id"`
+	Currency  currency_helpers.CurrencyCode `json:"currency" db:"currency"`
+	Action    BanAction                     `json:"action" db:"action"`
+	WindowID  int64                         `json:"windowId" db:"window_id"`
+	Reason    string                        `json:"reason,omitempty" db:"reason"`
+	Author    string                        `json:"author" db:"author"`
+	StartsAt  time.Time                     `json:"startsAt" db:"starts_at"`
+	EndsAt    *time.Time                    `json:"endsAt,omitempty" db:"ends_at"`
+	CreatedAt time.Time                     `json:"createdAt" db:"created_at"`
+}
+
+// BanEventFilter narrows the ban events feed, zero fields are not filtered on.
+type BanEventFilter struct {
+	Currency currency_helpers.CurrencyCode
+	Action   BanAction
+	Author   string
+	From     time.Time
+	To       time.Time
+	Limit    int
+}
+
+// BanEvents returns the events matching the filter, newest first.
+func (c *Catalogue) BanEvents(ctx context.Context, filter BanEventFilter) ([]BanEvent, error) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Currency.IsSet() {
+		where("currency = $%d", filter.Currency)
+	}
+	if filter.Action != "" {
+		where("action = $%d", filter.Action)
+	}
+	if filter.Author != "" {
+		where("author = $%d", filter.Author)
+	}
+	if !filter.From.IsZero() {
+		where("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		where("created_at < $%d", filter.To)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultBanEventsLimit
+	}
+	if limit > MaxBanEventsLimit {
+		limit = MaxBanEventsLimit
+	}
+	args = append(args, limit)
+
+	query := `
+		select id, currency, action, window_id, reason, author, starts_at, ends_at, created_at
+		from currency_ban_events
+	`
+	if len(conditions) > 0 {
+		query += "where " + strings.Join(conditions, " and ") + "\n"
+	}
+	query += fmt.Sprintf("order by id desc limit $%d;", len(args))
+
+	events := make([]BanEvent, 0)
+	err := c.db.SelectContext(ctx, &events, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "select ban events")
+	}
+
+	return events, nil
+}
+
+func recordEvent(ctx context.Context, tx *sqlx.Tx, event BanEvent) error {
+	query := `
+		insert into currency_ban_events (currency, action, window_id, reason, author, starts_at, ends_at)
+		values (:currency, :action, :window_id, :reason, :author, :starts_at, :ends_at);
+	`
+	_, err := tx.NamedExecContext(ctx, query, event)
+	if err != nil {
+		return errors.Wrap(err, "insert ban event")
+	}
+
+	return nil
+}
@@ -2,7 +2,10 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
 	"time"
+	"wallet-service/internal/currency_helpers"
 
 	"github.com/pkg/errors"
 )
@@ -14,6 +17,40 @@ type Config struct {
 	CacheTimeout                             time.Duration
 	ExchangerAPIURL                          string
 	ExchangerAPITimeout                      time.Duration
+	RateProviders                            []string
+	RateConsensus                            bool
+	RateConsensusMinSources                  int
+	RateConsensusMaxDeviation                currency_helpers.Decimal
+	CBRXMLURL                                string
+	ExchangeSpread                           currency_helpers.Decimal
+	QuoteTTL                                 time.Duration
+	CatalogueRefreshInterval                 time.Duration
+	TradingRulesRefreshInterval              time.Duration
+	JWTAlgorithm                             string
+	JWTSecret                                string
+	JWTPublicKeyFile                         string
+	JWTIssuer                                string
+	JWTAudience                              string
+	APIKeyRateLimit                          int
+	APIKeyDailyQuota                         int
+	APIKeysRefreshInterval                   time.Duration
+	AnonymousRateLimit                       int
+	MaxRequestBodySize                       int64
+	CORSAllowedOrigins                       []string
+	CORSAllowedMethods                       []string
+	CORSAllowedHeaders                       []string
+	CORSExposedHeaders                       []string
+	CORSAllowCredentials                     bool
+	CORSMaxAge                               time.Duration
+	PredictorEnabled                         bool
+	PredictorURL                             string
+	PredictorTimeout                         time.Duration
+	PredictorModel                           string
+	PredictorHorizon                         int
+	PredictionAccuracyInterval               time.Duration
+	PredictionJobWorkers                     int
+	PredictionJobPollInterval                time.Duration
+	PredictionJobTimeout                     time.Duration
 }
 
 func InitConfig() (*Config, error) {
@@ -72,17 +109,304 @@ func InitConfig() (*Config, error) {
 		return nil, errors.Wrap(err, "parse cbr api timeout")
 	}
 
+	rateProvidersStr, ok := os.LookupEnv("RATE_PROVIDERS")
+	if !ok {
+		rateProvidersStr = "exchangerate_host"
+	}
+	rateProviders := make([]string, 0)
+	for _, provider := range strings.Split(rateProvidersStr, ",") {
+		provider = strings.TrimSpace(provider)
+		if provider != "" {
+			rateProviders = append(rateProviders, provider)
+		}
+	}
+
+	rateConsensus := false
+	rateConsensusStr, ok := os.LookupEnv("RATE_CONSENSUS")
+	if ok {
+		rateConsensus, err = strconv.ParseBool(rateConsensusStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse rate consensus flag")
+		}
+	}
+	rateConsensusMinSources := 2
+	rateConsensusMinSourcesStr, ok := os.LookupEnv("RATE_CONSENSUS_MIN_SOURCES")
+	if ok {
+		rateConsensusMinSources, err = strconv.Atoi(rateConsensusMinSourcesStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse rate consensus min sources")
+		}
+	}
+	rateConsensusMaxDeviation := currency_helpers.NewDecimalFromInt(1)
+	rateConsensusMaxDeviationStr, ok := os.LookupEnv("RATE_CONSENSUS_MAX_DEVIATION")
+	if ok {
+		rateConsensusMaxDeviation, err = currency_helpers.ParseDecimal(rateConsensusMaxDeviationStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse rate consensus max deviation")
+		}
+	}
+	cbrXmlUrl, ok := os.LookupEnv("CBR_XML_URL")
+	if !ok {
+		cbrXmlUrl = "https://www.cbr.ru/scripts"
+	}
+
+	exchangeSpread := currency_helpers.Zero
+	exchangeSpreadStr, ok := os.LookupEnv("EXCHANGE_SPREAD")
+	if ok {
+		exchangeSpread, err = currency_helpers.ParseDecimal(exchangeSpreadStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse exchange spread")
+		}
+		if exchangeSpread.IsNegative() || exchangeSpread.GreaterThanOrEqual(currency_helpers.Hundred) {
+			return nil, errors.New("exchange spread must be in [0, 100) percent")
+		}
+	}
+
+	quoteTTL := 30 * time.Second
+	quoteTTLStr, ok := os.LookupEnv("QUOTE_TTL")
+	if ok {
+		quoteTTL, err = time.ParseDuration(quoteTTLStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse quote ttl")
+		}
+	}
+
+	catalogueRefreshInterval := 30 * time.Second
+	catalogueRefreshIntervalStr, ok := os.LookupEnv("CATALOGUE_REFRESH_INTERVAL")
+	if ok {
+		catalogueRefreshInterval, err = time.ParseDuration(catalogueRefreshIntervalStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse catalogue refresh interval")
+		}
+	}
+
+	tradingRulesRefreshInterval := 30 * time.Second
+	tradingRulesRefreshIntervalStr, ok := os.LookupEnv("TRADING_RULES_REFRESH_INTERVAL")
+	if ok {
+		tradingRulesRefreshInterval, err = time.ParseDuration(tradingRulesRefreshIntervalStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse trading rules refresh interval")
+		}
+	}
+
+	jwtAlgorithm, ok := os.LookupEnv("JWT_ALGORITHM")
+	if !ok {
+		jwtAlgorithm = "HS256"
+	}
+	jwtSecret, _ := os.LookupEnv("JWT_SECRET")
+	jwtPublicKeyFile, _ := os.LookupEnv("JWT_PUBLIC_KEY_FILE")
+	jwtIssuer, _ := os.LookupEnv("JWT_ISSUER")
+	jwtAudience, _ := os.LookupEnv("JWT_AUDIENCE")
+
+	apiKeyRateLimit := 60
+	apiKeyRateLimitStr, ok := os.LookupEnv("API_KEY_RATE_LIMIT")
+	if ok {
+		apiKeyRateLimit, err = strconv.Atoi(apiKeyRateLimitStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse api key rate limit")
+		}
+	}
+
+	apiKeyDailyQuota := 10000
+	apiKeyDailyQuotaStr, ok := os.LookupEnv("API_KEY_DAILY_QUOTA")
+	if ok {
+		apiKeyDailyQuota, err = strconv.Atoi(apiKeyDailyQuotaStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse api key daily quota")
+		}
+	}
+
+	apiKeysRefreshInterval := 30 * time.Second
+	apiKeysRefreshIntervalStr, ok := os.LookupEnv("API_KEYS_REFRESH_INTERVAL")
+	if ok {
+		apiKeysRefreshInterval, err = time.ParseDuration(apiKeysRefreshIntervalStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse api keys refresh interval")
+		}
+	}
+
+	// requests per minute from one address without an api key or a bearer token, zero disables the limit
+	anonymousRateLimit := 30
+	anonymousRateLimitStr, ok := os.LookupEnv("ANONYMOUS_RATE_LIMIT")
+	if ok {
+		anonymousRateLimit, err = strconv.Atoi(anonymousRateLimitStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse anonymous rate limit")
+		}
+	}
+
+	// bytes of a request body, larger ones are rejected with 413
+	maxRequestBodySize := int64(1 << 20)
+	maxRequestBodySizeStr, ok := os.LookupEnv("MAX_REQUEST_BODY_SIZE")
+	if ok {
+		maxRequestBodySize, err = strconv.ParseInt(maxRequestBodySizeStr, 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse max request body size")
+		}
+	}
+
+	corsAllowedOrigins, ok := os.LookupEnv("CORS_ALLOWED_ORIGINS")
+	if !ok {
+		corsAllowedOrigins = "*"
+	}
+	corsAllowedMethods, ok := os.LookupEnv("CORS_ALLOWED_METHODS")
+	if !ok {
+		corsAllowedMethods = "GET, POST, PUT, PATCH, DELETE"
+	}
+	corsAllowedHeaders, ok := os.LookupEnv("CORS_ALLOWED_HEADERS")
+	if !ok {
+		corsAllowedHeaders = "Authorization, Content-Type, X-API-Key"
+	}
+	corsExposedHeaders, ok := os.LookupEnv("CORS_EXPOSED_HEADERS")
+	if !ok {
+		corsExposedHeaders = "Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, X-Request-Id"
+	}
+
+	corsAllowCredentials := false
+	corsAllowCredentialsStr, ok := os.LookupEnv("CORS_ALLOW_CREDENTIALS")
+	if ok {
+		corsAllowCredentials, err = strconv.ParseBool(corsAllowCredentialsStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse cors allow credentials")
+		}
+	}
+
+	corsMaxAge := 10 * time.Minute
+	corsMaxAgeStr, ok := os.LookupEnv("CORS_MAX_AGE")
+	if ok {
+		corsMaxAge, err = time.ParseDuration(corsMaxAgeStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse cors max age")
+		}
+	}
+
+	predictorEnabled := true
+	predictorEnabledStr, ok := os.LookupEnv("PREDICTOR_ENABLED")
+	if ok {
+		predictorEnabled, err = strconv.ParseBool(predictorEnabledStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse predictor enabled flag")
+		}
+	}
+	predictorURL, ok := os.LookupEnv("PREDICTOR_URL")
+	if !ok {
+		predictorURL = "https://stbuddy.xyz/predict"
+	}
+	predictorTimeout := 30 * time.Second
+	predictorTimeoutStr, ok := os.LookupEnv("PREDICTOR_TIMEOUT")
+	if ok {
+		predictorTimeout, err = time.ParseDuration(predictorTimeoutStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse predictor timeout")
+		}
+	}
+	predictorModel, ok := os.LookupEnv("PREDICTOR_MODEL")
+	if !ok {
+		predictorModel = "remote"
+	}
+	predictorHorizon := 30
+	predictorHorizonStr, ok := os.LookupEnv("PREDICTOR_HORIZON")
+	if ok {
+		predictorHorizon, err = strconv.Atoi(predictorHorizonStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse predictor horizon")
+		}
+	}
+
+	predictionAccuracyInterval := time.Hour
+	predictionAccuracyIntervalStr, ok := os.LookupEnv("PREDICTION_ACCURACY_INTERVAL")
+	if ok {
+		predictionAccuracyInterval, err = time.ParseDuration(predictionAccuracyIntervalStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse prediction accuracy interval")
+		}
+	}
+
+	predictionJobWorkers := 2
+	predictionJobWorkersStr, ok := os.LookupEnv("PREDICTION_JOB_WORKERS")
+	if ok {
+		predictionJobWorkers, err = strconv.Atoi(predictionJobWorkersStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse prediction job workers")
+		}
+	}
+	predictionJobPollInterval := time.Second
+	predictionJobPollIntervalStr, ok := os.LookupEnv("PREDICTION_JOB_POLL_INTERVAL")
+	if ok {
+		predictionJobPollInterval, err = time.ParseDuration(predictionJobPollIntervalStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse prediction job poll interval")
+		}
+	}
+	if predictionJobPollInterval <= 0 {
+		return nil, errors.New("prediction job poll interval must be positive")
+	}
+	predictionJobTimeout := 10 * time.Minute
+	predictionJobTimeoutStr, ok := os.LookupEnv("PREDICTION_JOB_TIMEOUT")
+	if ok {
+		predictionJobTimeout, err = time.ParseDuration(predictionJobTimeoutStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse prediction job timeout")
+		}
+	}
+
 	config := &Config{
-		DBHost:              pgHost,
-		DBPort:              pgPort,
-		DBUser:              pgUser,
-		DBPass:              pgPass,
-		Database:            database,
-		DBTimeout:           pgTimeout,
-		CachePort:           redisPort,
-		CacheTimeout:        redisTimeout,
-		ExchangerAPIURL:     cbrApiUrl,
-		ExchangerAPITimeout: cbrApiTimeout,
+		DBHost:                      pgHost,
+		DBPort:                      pgPort,
+		DBUser:                      pgUser,
+		DBPass:                      pgPass,
+		Database:                    database,
+		DBTimeout:                   pgTimeout,
+		CachePort:                   redisPort,
+		CacheTimeout:                redisTimeout,
+		ExchangerAPIURL:             cbrApiUrl,
+		ExchangerAPITimeout:         cbrApiTimeout,
+		RateProviders:               rateProviders,
+		RateConsensus:               rateConsensus,
+		RateConsensusMinSources:     rateConsensusMinSources,
+		RateConsensusMaxDeviation:   rateConsensusMaxDeviation,
+		CBRXMLURL:                   cbrXmlUrl,
+		ExchangeSpread:              exchangeSpread,
+		QuoteTTL:                    quoteTTL,
+		CatalogueRefreshInterval:    catalogueRefreshInterval,
+		TradingRulesRefreshInterval: tradingRulesRefreshInterval,
+		JWTAlgorithm:                jwtAlgorithm,
+		JWTSecret:                   jwtSecret,
+		JWTPublicKeyFile:            jwtPublicKeyFile,
+		JWTIssuer:                   jwtIssuer,
+		JWTAudience:                 jwtAudience,
+		APIKeyRateLimit:             apiKeyRateLimit,
+		APIKeyDailyQuota:            apiKeyDailyQuota,
+		APIKeysRefreshInterval:      apiKeysRefreshInterval,
+		AnonymousRateLimit:          anonymousRateLimit,
+		MaxRequestBodySize:          maxRequestBodySize,
+		CORSAllowedOrigins:          splitList(corsAllowedOrigins),
+		CORSAllowedMethods:          splitList(corsAllowedMethods),
+		CORSAllowedHeaders:          splitList(corsAllowedHeaders),
+		CORSExposedHeaders:          splitList(corsExposedHeaders),
+		CORSAllowCredentials:        corsAllowCredentials,
+		CORSMaxAge:                  corsMaxAge,
+		PredictorEnabled:            predictorEnabled,
+		PredictorURL:                predictorURL,
+		PredictorTimeout:            predictorTimeout,
+		PredictorModel:              predictorModel,
+		PredictorHorizon:            predictorHorizon,
+		PredictionAccuracyInterval:  predictionAccuracyInterval,
+		PredictionJobWorkers:        predictionJobWorkers,
+		PredictionJobPollInterval:   predictionJobPollInterval,
+		PredictionJobTimeout:        predictionJobTimeout,
 	}
 	return config, nil
 }
+
+// splitList parses a comma separated env value, skipping empty items.
+func splitList(value string) []string {
+	result := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,182 @@
+package cors
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"wallet-service/internal/apierror"
+	"wallet-service/internal/config"
+
+	"github.com/pkg/errors"
+)
+
+// Policy decides which cross-origin requests browsers are allowed to make.
+// Origins are matched exactly, "*" allows any origin and a "*" inside an origin,
+// like https://*.example.com, matches one or more subdomain labels.
+type Policy struct {
+	anyOrigin   bool
+	origins     map[string]bool
+	patterns    []*regexp.Regexp
+	methods     map[string]bool
+	anyHeader   bool
+	headers     map[string]bool
+	credentials bool
+
+	allowMethods  string
+	allowHeaders  string
+	exposeHeaders string
+	maxAge        string
+}
+
+func InitPolicy(cfg *config.Config) (*Policy, error) {
+	p := &Policy{
+		origins:       make(map[string]bool),
+		methods:       make(map[string]bool),
+		headers:       make(map[string]bool),
+		credentials:   cfg.CORSAllowCredentials,
+		exposeHeaders: strings.Join(cfg.CORSExposedHeaders, ", "),
+	}
+
+	for _, origin := range cfg.CORSAllowedOrigins {
+		origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+		switch {
+		case origin == "*":
+			p.anyOrigin = true
+		case strings.Contains(origin, "*"):
+			pattern := strings.ReplaceAll(regexp.QuoteMeta(origin), `\*`, `[a-z0-9-]+(\.[a-z0-9-]+)*`)
+			p.patterns = append(p.patterns, regexp.MustCompile("^"+pattern+"$"))
+		default:
+			p.origins[origin] = true
+		}
+	}
+	// echoing any origin back together with credentials would let every site act as the user
+	if p.anyOrigin && p.credentials {
+		return nil, errors.New("cors credentials cannot be allowed for any origin")
+	}
+
+	methods := make([]string, 0, len(cfg.CORSAllowedMethods))
+	for _, method := range cfg.CORSAllowedMethods {
+		method = strings.ToUpper(method)
+		p.methods[method] = true
+		methods = append(methods, method)
+	}
+	p.allowMethods = strings.Join(methods, ", ")
+
+	for _, header := range cfg.CORSAllowedHeaders {
+		if header == "*" {
+			p.anyHeader = true
+			continue
+		}
+		p.headers[http.CanonicalHeaderKey(header)] = true
+	}
+	p.allowHeaders = strings.Join(cfg.CORSAllowedHeaders, ", ")
+
+	if cfg.CORSMaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(cfg.CORSMaxAge.Seconds()))
+	}
+
+	return p, nil
+}
+
+// Handler answers preflight requests itself and adds CORS headers to actual requests from allowed origins.
+// Requests from other origins are served without CORS headers, so browsers do not expose the response.
+func (p *Policy) Handler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			p.preflight(w, r, origin)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		if p.AllowsOrigin(origin) {
+			p.writeOrigin(w, origin)
+			if p.exposeHeaders != "" {
+				w.Header().Set("Access-Control-Expose-Headers", p.exposeHeaders)
+			}
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func (p *Policy) preflight(w http.ResponseWriter, r *http.Request, origin string) {
+	w.Header().Add("Vary", "Origin")
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	if !p.AllowsOrigin(origin) {
+		apierror.Write(w, r, apierror.New(http.StatusForbidden, apierror.CodeCORSRejected, "origin is not allowed"))
+		return
+	}
+	method := r.Header.Get("Access-Control-Request-Method")
+	if !p.methods[strings.ToUpper(method)] {
+		apierror.Write(w, r, apierror.New(http.StatusForbidden, apierror.CodeCORSRejected, "method is not allowed"))
+		return
+	}
+	requested := r.Header.Get("Access-Control-Request-Headers")
+	if !p.allowsHeaders(requested) {
+		apierror.Write(w, r, apierror.New(http.StatusForbidden, apierror.CodeCORSRejected, "headers are not allowed"))
+		return
+	}
+
+	p.writeOrigin(w, origin)
+	w.Header().Set("Access-Control-Allow-Methods", p.allowMethods)
+	switch {
+	case p.anyHeader && requested != "":
+		// the wildcard is not honoured for requests with credentials, so the requested headers are echoed
+		w.Header().Set("Access-Control-Allow-Headers", requested)
+	case !p.anyHeader && p.allowHeaders != "":
+		w.Header().Set("Access-Control-Allow-Headers", p.allowHeaders)
+	}
+	if p.maxAge != "" {
+		w.Header().Set("Access-Control-Max-Age", p.maxAge)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (p *Policy) AllowsOrigin(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if p.origins[origin] {
+		return true
+	}
+	for _, pattern := range p.patterns {
+		if pattern.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsHeaders checks the comma separated Access-Control-Request-Headers value.
+func (p *Policy) allowsHeaders(requested string) bool {
+	if p.anyHeader {
+		return true
+	}
+	for _, header := range strings.Split(requested, ",") {
+		header = strings.TrimSpace(header)
+		if header != "" && !p.headers[http.CanonicalHeaderKey(header)] {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Policy) writeOrigin(w http.ResponseWriter, origin string) {
+	if p.anyOrigin {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if p.credentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
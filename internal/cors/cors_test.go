@@ -0,0 +1,302 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+	"wallet-service/internal/config"
+)
+
+func testConfig(origins ...string) *config.Config {
+	return &config.Config{
+		CORSAllowedOrigins: origins,
+		CORSAllowedMethods: []string{"get", "POST"},
+		CORSAllowedHeaders: []string{"Authorization", "Content-Type"},
+		CORSExposedHeaders: []string{"Retry-After", "X-Request-Id"},
+		CORSMaxAge:         10 * time.Minute,
+	}
+}
+
+func testPolicy(t *testing.T, cfg *config.Config) *Policy {
+	t.Helper()
+	p, err := InitPolicy(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestInitPolicyCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		origins []string
+		wantErr bool
+	}{
+		{"any origin", []string{"*"}, true},
+		{"any origin among others", []string{"https://app.example.com", "*"}, true},
+		{"exact origin", []string{"https://app.example.com"}, false},
+		{"subdomain pattern", []string{"https://*.example.com"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(tt.origins...)
+			cfg.CORSAllowCredentials = true
+			_, err := InitPolicy(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAllowsOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		origins []string
+		origin  string
+		want    bool
+	}{
+		{"exact", []string{"https://app.example.com"}, "https://app.example.com", true},
+		{"exact in other case", []string{"https://App.Example.com/"}, "https://app.EXAMPLE.com", true},
+		{"exact on other scheme", []string{"https://app.example.com"}, "http://app.example.com", false},
+		{"exact on other port", []string{"https://app.example.com"}, "https://app.example.com:8443", false},
+		{"exact subdomain", []string{"https://example.com"}, "https://app.example.com", false},
+		{"wildcard", []string{"*"}, "https://anything.test", true},
+		{"pattern", []string{"https://*.example.com"}, "https://app.example.com", true},
+		{"pattern with nested subdomains", []string{"https://*.example.com"}, "https://eu.app.example.com", true},
+		{"pattern without subdomain", []string{"https://*.example.com"}, "https://example.com", false},
+		{"pattern with suffix", []string{"https://*.example.com"}, "https://app.example.com.evil.test", false},
+		{"pattern with prefix", []string{"https://*.example.com"}, "https://evilexample.com", false},
+		{"pattern on other scheme", []string{"https://*.example.com"}, "http://app.example.com", false},
+		{"pattern escaping the dot", []string{"https://*.example.com"}, "https://app.examplexcom", false},
+		{"nothing allowed", nil, "https://app.example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testPolicy(t, testConfig(tt.origins...))
+			if got := p.AllowsOrigin(tt.origin); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		origins     []string
+		credentials bool
+		origin      string
+		wantHeaders http.Header
+	}{
+		{
+			name:        "no origin",
+			origins:     []string{"https://app.example.com"},
+			wantHeaders: http.Header{},
+		},
+		{
+			name:        "allowed origin",
+			origins:     []string{"https://app.example.com"},
+			credentials: true,
+			origin:      "https://app.example.com",
+			wantHeaders: http.Header{
+				"Vary":                             {"Origin"},
+				"Access-Control-Allow-Origin":      {"https://app.example.com"},
+				"Access-Control-Allow-Credentials": {"true"},
+				"Access-Control-Expose-Headers":    {"Retry-After, X-Request-Id"},
+			},
+		},
+		{
+			name:    "any origin",
+			origins: []string{"*"},
+			origin:  "https://app.example.com",
+			wantHeaders: http.Header{
+				"Vary":                          {"Origin"},
+				"Access-Control-Allow-Origin":   {"*"},
+				"Access-Control-Expose-Headers": {"Retry-After, X-Request-Id"},
+			},
+		},
+		{
+			// served without CORS headers, the browser keeps the response from the page
+			name:        "disallowed origin",
+			origins:     []string{"https://app.example.com"},
+			credentials: true,
+			origin:      "https://evil.test",
+			wantHeaders: http.Header{"Vary": {"Origin"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(tt.origins...)
+			cfg.CORSAllowCredentials = tt.credentials
+			called := false
+			handler := testPolicy(t, cfg).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/currency/available", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if !called {
+				t.Error("handler not called")
+			}
+			if !reflect.DeepEqual(w.Header(), tt.wantHeaders) {
+				t.Errorf("got headers %v, want %v", w.Header(), tt.wantHeaders)
+			}
+		})
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	vary := []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}
+	rejected := func(message string) string {
+		return `{"code":"cors_rejected","message":"` + message + `"}` + "\n"
+	}
+
+	tests := []struct {
+		name        string
+		cfg         func(cfg *config.Config)
+		origin      string
+		method      string
+		headers     string
+		wantStatus  int
+		wantHeaders http.Header
+		wantBody    string
+	}{
+		{
+			name:       "allowed",
+			origin:     "https://app.example.com",
+			method:     "POST",
+			headers:    "content-type, authorization",
+			wantStatus: http.StatusNoContent,
+			wantHeaders: http.Header{
+				"Vary":                         vary,
+				"Access-Control-Allow-Origin":  {"https://app.example.com"},
+				"Access-Control-Allow-Methods": {"GET, POST"},
+				"Access-Control-Allow-Headers": {"Authorization, Content-Type"},
+				"Access-Control-Max-Age":       {"600"},
+			},
+		},
+		{
+			name:       "subdomain with credentials and without max age",
+			cfg:        func(cfg *config.Config) { cfg.CORSAllowCredentials, cfg.CORSMaxAge = true, 0 },
+			origin:     "https://eu.app.example.com",
+			method:     "GET",
+			wantStatus: http.StatusNoContent,
+			wantHeaders: http.Header{
+				"Vary":                             vary,
+				"Access-Control-Allow-Origin":      {"https://eu.app.example.com"},
+				"Access-Control-Allow-Credentials": {"true"},
+				"Access-Control-Allow-Methods":     {"GET, POST"},
+				"Access-Control-Allow-Headers":     {"Authorization, Content-Type"},
+			},
+		},
+		{
+			name:       "any header echoes the requested ones",
+			cfg:        func(cfg *config.Config) { cfg.CORSAllowedHeaders = []string{"*"} },
+			origin:     "https://app.example.com",
+			method:     "POST",
+			headers:    "X-Custom",
+			wantStatus: http.StatusNoContent,
+			wantHeaders: http.Header{
+				"Vary":                         vary,
+				"Access-Control-Allow-Origin":  {"https://app.example.com"},
+				"Access-Control-Allow-Methods": {"GET, POST"},
+				"Access-Control-Allow-Headers": {"X-Custom"},
+				"Access-Control-Max-Age":       {"600"},
+			},
+		},
+		{
+			name:       "disallowed origin",
+			origin:     "https://evil.test",
+			method:     "POST",
+			wantStatus: http.StatusForbidden,
+			wantBody:   rejected("origin is not allowed"),
+		},
+		{
+			name:       "disallowed method",
+			origin:     "https://app.example.com",
+			method:     "DELETE",
+			wantStatus: http.StatusForbidden,
+			wantBody:   rejected("method is not allowed"),
+		},
+		{
+			name:       "disallowed header",
+			origin:     "https://app.example.com",
+			method:     "POST",
+			headers:    "Content-Type, X-API-Key",
+			wantStatus: http.StatusForbidden,
+			wantBody:   rejected("headers are not allowed"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig("https://app.example.com", "https://*.app.example.com")
+			if tt.cfg != nil {
+				tt.cfg(cfg)
+			}
+			called := false
+			handler := testPolicy(t, cfg).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			}))
+
+			r := httptest.NewRequest(http.MethodOptions, "/currency/quote", nil)
+			r.Header.Set("Origin", tt.origin)
+			r.Header.Set("Access-Control-Request-Method", tt.method)
+			if tt.headers != "" {
+				r.Header.Set("Access-Control-Request-Headers", tt.headers)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if called {
+				t.Error("preflight reached the handler")
+			}
+			if w.Code != tt.wantStatus {
+				t.Errorf("got status %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusNoContent {
+				if !reflect.DeepEqual(w.Header()["Vary"], vary) {
+					t.Errorf("got Vary %v, want %v", w.Header()["Vary"], vary)
+				}
+				if origin := w.Header().Get("Access-Control-Allow-Origin"); origin != "" {
+					t.Errorf("rejected preflight allows origin %q", origin)
+				}
+				if w.Body.String() != tt.wantBody {
+					t.Errorf("got body %q, want %q", w.Body.String(), tt.wantBody)
+				}
+				return
+			}
+			if !reflect.DeepEqual(w.Header(), tt.wantHeaders) {
+				t.Errorf("got headers %v, want %v", w.Header(), tt.wantHeaders)
+			}
+			if w.Body.Len() != 0 {
+				t.Errorf("got body %q", w.Body.String())
+			}
+		})
+	}
+}
+
+// TestOptionsWithoutPreflight passes OPTIONS requests without Access-Control-Request-Method to the handler.
+func TestOptionsWithoutPreflight(t *testing.T) {
+	called := false
+	handler := testPolicy(t, testConfig("https://app.example.com")).Handler(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}),
+	)
+
+	r := httptest.NewRequest(http.MethodOptions, "/currency/quote", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	if !called {
+		t.Error("handler not called")
+	}
+}
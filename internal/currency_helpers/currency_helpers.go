@@ -3,10 +3,9 @@ package currency_helpers
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
-type Currency int
-
 type CurrencyCode string
 
 func (c CurrencyCode) String() string {
@@ -27,10 +26,29 @@ func (c *CurrencyCode) IsSet() bool {
 	return *c != ""
 }
 
+// CurrencyInfo is ISO 4217 metadata of a currency, kept in the currency catalogue.
+// NumericCode is empty for codes which have no ISO numeric code.
+type CurrencyInfo struct {
+	Code        CurrencyCode `json:"code" db:"code"`
+	NumericCode string       `json:"numericCode,omitempty" db:"numeric_code"`
+	NameEn      string       `json:"nameEn" db:"name_en"`
+	NameRu      string       `json:"nameRu" db:"name_ru"`
+	Symbol      string       `json:"symbol" db:"symbol"`
+	MinorUnits  int32        `json:"minorUnits" db:"minor_units"`
+	Crypto      bool         `json:"crypto" db:"crypto"`
+	NonISO      bool         `json:"nonIso" db:"non_iso"`
+}
+
 const (
 	CurrentTimeRateCollection string = "rate:collection"
 	AvailableCurrencies       string = "available"
 	TimeCollection            string = "time:collection"
+	QuoteCollection           string = "quote"
+	UsedQuoteCollection       string = "quote:used"
+	APIKeyRateCollection      string = "apikey:rate"
+	APIKeyQuotaCollection     string = "apikey:quota"
+	AnonymousRateCollection   string = "anonymous:rate"
+	PredictionCollection      string = "prediction"
 )
 
 type CurrencyRatesResponse struct {
@@ -39,9 +57,19 @@ type CurrencyRatesResponse struct {
 }
 
 type CurrencyRates struct {
-	Base  CurrencyCode             `json:"base"`
-	Rates map[CurrencyCode]float64 `json:"rates"`
-	Date  CustomTime               `json:"date"`
+	Base    CurrencyCode             `json:"base"`
+	Rates   map[CurrencyCode]Decimal `json:"rates"`
+	Date    CustomTime               `json:"date"`
+	Source  string                   `json:"source,omitempty"`
+	Sources map[CurrencyCode]string  `json:"sources,omitempty"`
+}
+
+// SourceOf returns the provider the rate of the currency came from.
+func (cr CurrencyRates) SourceOf(currencyCode CurrencyCode) string {
+	if source, ok := cr.Sources[currencyCode]; ok {
+		return source
+	}
+	return cr.Source
 }
 
 func (cr CurrencyRates) ToResultRate(currencyCode CurrencyCode) *CurrencyRate {
@@ -50,6 +78,7 @@ func (cr CurrencyRates) ToResultRate(currencyCode CurrencyCode) *CurrencyRate {
 		Second: currencyCode,
 		Rate:   cr.Rates[currencyCode],
 		Date:   cr.Date,
+		Source: cr.SourceOf(currencyCode),
 	}
 }
 
@@ -60,22 +89,24 @@ type CurrencyTimelineRatesResponse struct {
 
 type CurrencyTimelineRates struct {
 	Base      CurrencyCode                            `json:"base"`
-	Rates     map[CustomTime]map[CurrencyCode]float64 `json:"rates"`
+	Rates     map[CustomTime]map[CurrencyCode]Decimal `json:"rates"`
 	StartDate CustomTime                              `json:"start_date"`
 	EndDate   CustomTime                              `json:"end_date"`
+	Source    string                                  `json:"source,omitempty"`
 }
 
 type CurrencyTimelineRate struct {
 	Base        CurrencyCode           `json:"base"`
 	Second      CurrencyCode           `json:"second"`
-	Rates       map[CustomTime]float64 `json:"rates"`
-	Predictions map[CustomTime]float64 `json:"predictions,omitempty"`
+	Rates       map[CustomTime]Decimal `json:"rates"`
+	Predictions *Forecast              `json:"predictions,omitempty"`
 	StartDate   CustomTime             `json:"startDate"`
 	EndDate     CustomTime             `json:"endDate"`
+	Source      string                 `json:"source,omitempty"`
 }
 
 func (cr CurrencyTimelineRates) ToResultTimelineRates(currencyCode CurrencyCode) *CurrencyTimelineRate {
-	rates := make(map[CustomTime]float64, len(cr.Rates))
+	rates := make(map[CustomTime]Decimal, len(cr.Rates))
 	for periodTime, rate := range cr.Rates {
 		rates[periodTime] = rate[currencyCode]
 	}
@@ -86,17 +117,69 @@ func (cr CurrencyTimelineRates) ToResultTimelineRates(currencyCode CurrencyCode)
 		Rates:     rates,
 		StartDate: cr.StartDate,
 		EndDate:   cr.EndDate,
+		Source:    cr.Source,
 	}
 }
 
+// PredictedRate is a forecast of the rate for the date with the bounds of its confidence interval,
+// the bounds are nil when the model does not estimate them.
+type PredictedRate struct {
+	Date  CustomTime `json:"date"`
+	Rate  Decimal    `json:"rate"`
+	Lower *Decimal   `json:"lower,omitempty"`
+	Upper *Decimal   `json:"upper,omitempty"`
+}
+
+// Forecast holds the predicted rates of a pair and describes how they were made:
+// the model, the dates of the history it was trained on and when it was generated.
+// ConfidenceLevel is the probability the bounds of the points are estimated for.
+type Forecast struct {
+	Model           string          `json:"model"`
+	ModelVersion    string          `json:"modelVersion,omitempty"`
+	TrainingStart   CustomTime      `json:"trainingStart"`
+	TrainingEnd     CustomTime      `json:"trainingEnd"`
+	GeneratedAt     time.Time       `json:"generatedAt"`
+	ConfidenceLevel float64         `json:"confidenceLevel,omitempty"`
+	Points          []PredictedRate `json:"points"`
+}
+
 type CurrencyRate struct {
 	Base   CurrencyCode `json:"base"`
 	Second CurrencyCode `json:"second"`
-	Rate   float64      `json:"rate"`
+	Rate   Decimal      `json:"rate"`
 	Date   CustomTime   `json:"date"`
+	Source string       `json:"source,omitempty"`
 }
 
+// CurrencyWithBanStatus describes a currency and its active ban, BannedUntil is nil for indefinite bans.
 type CurrencyWithBanStatus struct {
-	Currency CurrencyCode `json:"currency"`
-	Banned   bool         `json:"banned"`
+	Currency    CurrencyCode  `json:"currency"`
+	Banned      bool          `json:"banned"`
+	BanReason   string        `json:"banReason,omitempty"`
+	BannedUntil *time.Time    `json:"bannedUntil,omitempty"`
+	Info        *CurrencyInfo `json:"info,omitempty"`
+}
+
+// Quote is a rate of the pair locked for the amount of base currency until ExpiresAt.
+// Only Owner, the subject of the user who requested it, may exchange at it.
+type Quote struct {
+	ID        string       `json:"id"`
+	Owner     string       `json:"owner"`
+	Base      CurrencyCode `json:"base"`
+	Second    CurrencyCode `json:"second"`
+	Rate      Decimal      `json:"rate"`
+	Amount    Decimal      `json:"amount"`
+	Date      CustomTime   `json:"date"`
+	Source    string       `json:"source,omitempty"`
+	ExpiresAt time.Time    `json:"expiresAt"`
+}
+
+func (q Quote) ToResultRate() *CurrencyRate {
+	return &CurrencyRate{
+		Base:   q.Base,
+		Second: q.Second,
+		Rate:   q.Rate,
+		Date:   q.Date,
+		Source: q.Source,
+	}
 }
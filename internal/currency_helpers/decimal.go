@@ -0,0 +1,76 @@
+package currency_helpers
+
+import (
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// Decimal is an exact decimal number used for rates and amounts instead of float64.
+// It is encoded in JSON as a string, decoded from both strings and numbers,
+// and scanned from Postgres NUMERIC as is.
+type Decimal = decimal.Decimal
+
+// RateScale is the number of decimal places rates are calculated and stored with.
+const RateScale int32 = 18
+
+var (
+	Zero    = decimal.Zero
+	Hundred = decimal.NewFromInt(100)
+)
+
+func ParseDecimal(s string) (Decimal, error) {
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return Zero, errors.Wrapf(err, "parse decimal '%s'", s)
+	}
+
+	return d, nil
+}
+
+func NewDecimalFromInt(i int64) Decimal {
+	return decimal.NewFromInt(i)
+}
+
+// NewDecimalFromFloat converts the float using its shortest exact representation.
+func NewDecimalFromFloat(f float64) Decimal {
+	return decimal.NewFromFloat(f)
+}
+
+// DivideRate divides rates keeping RateScale decimal places.
+func DivideRate(a, b Decimal) Decimal {
+	return a.DivRound(b, RateScale)
+}
+
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds half away from zero.
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds half to the even neighbour (banker's rounding).
+	RoundHalfEven
+	// RoundDown truncates towards zero.
+	RoundDown
+	// RoundUp rounds away from zero.
+	RoundUp
+	// RoundFloor rounds towards negative infinity.
+	RoundFloor
+	// RoundCeiling rounds towards positive infinity.
+	RoundCeiling
+)
+
+func Round(d Decimal, places int32, mode RoundingMode) Decimal {
+	switch mode {
+	case RoundHalfEven:
+		return d.RoundBank(places)
+	case RoundDown:
+		return d.RoundDown(places)
+	case RoundUp:
+		return d.RoundUp(places)
+	case RoundFloor:
+		return d.RoundFloor(places)
+	case RoundCeiling:
+		return d.RoundCeil(places)
+	default:
+		return d.Round(places)
+	}
+}
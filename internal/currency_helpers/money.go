@@ -0,0 +1,109 @@
+package currency_helpers
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// Money is an exact amount in a currency, MinorUnits is the number of decimal places
+// amounts in the currency are kept with, as set in the currency catalogue.
+type Money struct {
+	Amount     Decimal      `json:"amount"`
+	Currency   CurrencyCode `json:"currency"`
+	MinorUnits int32        `json:"-"`
+}
+
+func NewMoney(amount Decimal, currency CurrencyCode, minorUnits int32) Money {
+	return Money{
+		Amount:     amount,
+		Currency:   currency,
+		MinorUnits: minorUnits,
+	}
+}
+
+// ParseMoney parses a positive amount which has no more decimal places than the currency minor units.
+func ParseMoney(s string, currency CurrencyCode, minorUnits int32) (Money, error) {
+	amount, err := ParseDecimal(s)
+	if err != nil {
+		return Money{}, ErrInvalidAmount
+	}
+
+	m := NewMoney(amount, currency, minorUnits)
+	err = m.Validate()
+	if err != nil {
+		return Money{}, err
+	}
+
+	return m, nil
+}
+
+// Validate checks that the amount is positive and fits into the currency minor units.
+func (m Money) Validate() error {
+	if !m.Amount.IsPositive() {
+		return errors.Wrap(ErrInvalidAmount, "amount must be positive")
+	}
+	if !m.IsExact() {
+		return errors.Wrapf(
+			ErrInvalidAmount, "'%s' allows at most %d decimal places", m.Currency, m.MinorUnits,
+		)
+	}
+
+	return nil
+}
+
+// IsExact reports whether the amount has no digits beyond the currency minor units.
+func (m Money) IsExact() bool {
+	return m.Amount.Equal(m.Amount.Truncate(m.MinorUnits))
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, errors.Wrapf(ErrCurrencyMismatch, "%s and %s", m.Currency, other.Currency)
+	}
+	return NewMoney(m.Amount.Add(other.Amount), m.Currency, m.MinorUnits), nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, errors.Wrapf(ErrCurrencyMismatch, "%s and %s", m.Currency, other.Currency)
+	}
+	return NewMoney(m.Amount.Sub(other.Amount), m.Currency, m.MinorUnits), nil
+}
+
+func (m Money) Neg() Money {
+	return NewMoney(m.Amount.Neg(), m.Currency, m.MinorUnits)
+}
+
+// Round rounds the amount to the currency minor units.
+func (m Money) Round(mode RoundingMode) Money {
+	return NewMoney(Round(m.Amount, m.MinorUnits, mode), m.Currency, m.MinorUnits)
+}
+
+// Convert converts the amount into the currency by the rate,
+// rounding the result to the minor units of the target currency.
+func (m Money) Convert(rate Decimal, currency CurrencyCode, minorUnits int32, mode RoundingMode) Money {
+	return NewMoney(m.Amount.Mul(rate), currency, minorUnits).Round(mode)
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount.IsPositive()
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount.IsZero()
+}
+
+// StringFixed formats the amount with all minor unit places.
+func (m Money) StringFixed() string {
+	return m.Amount.StringFixed(m.MinorUnits)
+}
+
+func (m Money) String() string {
+	return fmt.Sprintf("%s %s", m.StringFixed(), m.Currency)
+}
@@ -31,6 +31,15 @@ func (ct *CustomTime) UnmarshalJSON(b []byte) (err error) {
 	return
 }
 
+// UnmarshalText parses map keys, providers key daily rates by date while the cache keeps them in RFC 3339.
+func (ct *CustomTime) UnmarshalText(b []byte) (err error) {
+	ct.Time, err = time.Parse(CustomTimeLayout, string(b))
+	if err != nil {
+		ct.Time, err = time.Parse(time.RFC3339, string(b))
+	}
+	return
+}
+
 func (ct *CustomTime) MarshalJSON() ([]byte, error) {
 	if ct.Time.UnixNano() == nilTime {
 		return []byte("null"), nil
@@ -0,0 +1,269 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"runtime/debug"
+	"time"
+	"wallet-service/internal/config"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+type Status string
+
+const (
+	StatusQueued  Status = "queued"
+	StatusRunning Status = "running"
+	StatusDone    Status = "done"
+	StatusFailed  Status = "failed"
+)
+
+const jobColumns = `id, base_currency, second_currency, model, status, error, forecast, created_at, started_at, finished_at`
+
+var (
+	ErrJobNotFound = errors.New("prediction job not found")
+	errPanicked    = errors.New("prediction job panicked")
+)
+
+// Job generates the forecast of a pair by a model. The forecast is set once the job is done,
+// Error once it failed, holding only the cause of the failure as it is shown to clients.
+type Job struct {
+	ID         int64                         `json:"id" db:"id"`
+	Base       currency_helpers.CurrencyCode `json:"base" db:"base_currency"`
+	Second     currency_helpers.CurrencyCode `json:"second" db:"second_currency"`
+	Model      string                        `json:"model" db:"model"`
+	Status     Status                        `json:"status" db:"status"`
+	Error      string                        `json:"error,omitempty" db:"error"`
+	Forecast   *currency_helpers.Forecast    `json:"forecast,omitempty" db:"-"`
+	CreatedAt  time.Time                     `json:"createdAt" db:"created_at"`
+	StartedAt  *time.Time                    `json:"startedAt,omitempty" db:"started_at"`
+	FinishedAt *time.Time                    `json:"finishedAt,omitempty" db:"finished_at"`
+}
+
+// Worker generates the forecast of the job.
+type Worker func(ctx context.Context, job Job) (*currency_helpers.Forecast, error)
+
+type jobRow struct {
+	Job
+	Forecast []byte `db:"forecast"`
+}
+
+func (row *jobRow) job() (*Job, error) {
+	job := row.Job
+	if row.Forecast != nil {
+		job.Forecast = &currency_helpers.Forecast{}
+		err := json.Unmarshal(row.Forecast, job.Forecast)
+		if err != nil {
+			return nil, errors.Wrap(err, "unmarshal forecast")
+		}
+	}
+	return &job, nil
+}
+
+// Queue keeps prediction jobs in Postgres, so requests return right away and the jobs are shared
+// by all instances. Workers claim jobs with skip locked and take over running jobs
+// whose instance stopped before finishing them.
+type Queue struct {
+	db  *sqlx.DB
+	cfg *config.Config
+}
+
+func InitQueue(db *sqlx.DB, cfg *config.Config) *Queue {
+	return &Queue{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// Start runs the configured number of workers polling for queued jobs.
+func (q *Queue) Start(worker Worker) {
+	for i := 0; i < q.cfg.PredictionJobWorkers; i++ {
+		go q.workLoop(worker)
+	}
+}
+
+func (q *Queue) workLoop(worker Worker) {
+	ticker := time.NewTicker(q.cfg.PredictionJobPollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		// drain the queue before waiting for the next tick
+		for q.runNext(worker) {
+		}
+	}
+}
+
+// runNext runs one claimed job and reports whether there was one.
+func (q *Queue) runNext(worker Worker) bool {
+	dbCtx, cancel := context.WithTimeout(context.Background(), q.cfg.DBTimeout)
+	job, err := q.claim(dbCtx)
+	cancel()
+	if err != nil {
+		log.Printf("error in claim prediction job: %s", err.Error())
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), q.cfg.PredictionJobTimeout)
+	forecast, err := run(ctx, worker, *job)
+	cancel()
+	if err != nil {
+		log.Printf("error in prediction job %d of %s/%s by %s: %s", job.ID, job.Base, job.Second, job.Model, err.Error())
+	}
+
+	dbCtx, cancel = context.WithTimeout(context.Background(), q.cfg.DBTimeout)
+	defer cancel()
+	err = q.finish(dbCtx, job.ID, forecast, err)
+	if err != nil {
+		log.Printf("error in finish prediction job %d: %s", job.ID, err.Error())
+	}
+
+	return true
+}
+
+// run calls the worker, a panic fails the job instead of stopping the service.
+func run(ctx context.Context, worker Worker, job Job) (forecast *currency_helpers.Forecast, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Printf("panic in prediction job %d: %v\n%s", job.ID, recovered, debug.Stack())
+			forecast, err = nil, errors.Wrapf(errPanicked, "%v", recovered)
+		}
+	}()
+
+	return worker(ctx, job)
+}
+
+// latestJobQuery selects the job of the pair and the model queued or running, or else finished since $4.
+const latestJobQuery = `
+	select ` + jobColumns + ` from prediction_jobs
+	where base_currency = $1 and second_currency = $2 and model = $3
+		and (status in ('queued', 'running') or finished_at >= $4)
+	order by id desc
+	limit 1`
+
+// Enqueue adds a job generating the forecast of the pair by the model, or returns the job already
+// queued or running for them. A job finished today is returned as well, whether done or failed,
+// so a pair failing to predict is retried the next day instead of on every request.
+func (q *Queue) Enqueue(
+	ctx context.Context,
+	base currency_helpers.CurrencyCode,
+	second currency_helpers.CurrencyCode,
+	model string,
+) (*Job, error) {
+	query := `
+		with existing as (` + latestJobQuery + `
+		), inserted as (
+			insert into prediction_jobs (base_currency, second_currency, model)
+			select $1, $2, $3
+			where not exists (select 1 from existing)
+			on conflict (base_currency, second_currency, model) where status in ('queued', 'running') do nothing
+			returning ` + jobColumns + `
+		)
+		select ` + jobColumns + ` from existing
+		union all
+		select ` + jobColumns + ` from inserted
+		limit 1;`
+	row := &jobRow{}
+	err := q.db.QueryRowxContext(ctx, query, base, second, model, startOfToday()).StructScan(row)
+	if err != nil {
+		return nil, errors.Wrap(err, "insert prediction job")
+	}
+
+	return row.job()
+}
+
+// Find returns the job Enqueue would return for the pair and the model without adding one,
+// nil when there is none.
+func (q *Queue) Find(
+	ctx context.Context,
+	base currency_helpers.CurrencyCode,
+	second currency_helpers.CurrencyCode,
+	model string,
+) (*Job, error) {
+	row := &jobRow{}
+	err := q.db.QueryRowxContext(ctx, latestJobQuery+";", base, second, model, startOfToday()).StructScan(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "select prediction job")
+	}
+
+	return row.job()
+}
+
+func startOfToday() time.Time {
+	now := time.Now()
+	year, month, day := now.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+}
+
+func (q *Queue) Get(ctx context.Context, id int64) (*Job, error) {
+	row := &jobRow{}
+	err := q.db.QueryRowxContext(ctx, "select "+jobColumns+" from prediction_jobs where id = $1;", id).StructScan(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.Wrapf(ErrJobNotFound, "%d", id)
+		}
+		return nil, errors.Wrap(err, "select prediction job")
+	}
+
+	return row.job()
+}
+
+// claim marks the oldest queued job running, a running job is taken over
+// once it has been running for twice the job timeout. Returns nil when there is no job.
+func (q *Queue) claim(ctx context.Context) (*Job, error) {
+	query := `
+		update prediction_jobs
+		set status = 'running', started_at = now()
+		where id = (
+			select id from prediction_jobs
+			where status = 'queued' or (status = 'running' and started_at < $1)
+			order by id
+			limit 1
+			for update skip locked
+		)
+		returning ` + jobColumns + `;`
+	row := &jobRow{}
+	err := q.db.QueryRowxContext(ctx, query, time.Now().Add(-2*q.cfg.PredictionJobTimeout)).StructScan(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "update prediction job")
+	}
+
+	return row.job()
+}
+
+func (q *Queue) finish(ctx context.Context, id int64, forecast *currency_helpers.Forecast, jobErr error) error {
+	status, message := StatusDone, ""
+	var result interface{}
+	if jobErr != nil {
+		status, message = StatusFailed, errors.Cause(jobErr).Error()
+	} else {
+		encoded, err := json.Marshal(forecast)
+		if err != nil {
+			return errors.Wrap(err, "marshal forecast")
+		}
+		result = string(encoded)
+	}
+
+	_, err := q.db.ExecContext(ctx, `
+		update prediction_jobs set status = $1, error = $2, forecast = $3, finished_at = now()
+		where id = $4;
+	`, status, message, result, id)
+	if err != nil {
+		return errors.Wrap(err, "update prediction job")
+	}
+
+	return nil
+}
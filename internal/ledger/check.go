@@ -0,0 +1,97 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+type UnbalancedEntry struct {
+	EntryID  int64                         `json:"entryId" db:"entry_id"`
+	Currency currency_helpers.CurrencyCode `json:"currency" db:"currency"`
+	Sum      currency_helpers.Decimal      `json:"sum" db:"sum"`
+}
+
+type MismatchedAccount struct {
+	AccountID     int64                         `json:"accountId" db:"account_id"`
+	Currency      currency_helpers.CurrencyCode `json:"currency" db:"currency"`
+	Balance       currency_helpers.Decimal      `json:"balance" db:"balance"`
+	LedgerBalance currency_helpers.Decimal      `json:"ledgerBalance" db:"ledger_balance"`
+}
+
+type OverdrawnAccount struct {
+	AccountID int64                         `json:"accountId" db:"account_id"`
+	Currency  currency_helpers.CurrencyCode `json:"currency" db:"currency"`
+	Balance   currency_helpers.Decimal      `json:"balance" db:"balance"`
+}
+
+// Report is the result of checking the ledger invariants:
+// every entry sums to zero per currency, every balance equals the sum of its postings
+// and no user account is negative.
+type Report struct {
+	OK                 bool                `json:"ok"`
+	UnbalancedEntries  []UnbalancedEntry   `json:"unbalancedEntries"`
+	MismatchedAccounts []MismatchedAccount `json:"mismatchedAccounts"`
+	OverdrawnAccounts  []OverdrawnAccount  `json:"overdrawnAccounts"`
+}
+
+func Check(ctx context.Context, db *sqlx.DB) (*Report, error) {
+	report := &Report{
+		UnbalancedEntries:  []UnbalancedEntry{},
+		MismatchedAccounts: []MismatchedAccount{},
+		OverdrawnAccounts:  []OverdrawnAccount{},
+	}
+
+	// all checks must see the same snapshot
+	tx, err := db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, errors.Wrap(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	query := `
+		select p.entry_id, p.currency, sum(p.amount) as sum
+		from postings as p
+		group by p.entry_id, p.currency
+		having sum(p.amount) <> 0
+		order by p.entry_id;
+	`
+	err = tx.SelectContext(ctx, &report.UnbalancedEntries, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "check entries balance")
+	}
+
+	query = `
+		select wa.id as account_id, wa.currency, wa.balance, coalesce(sum(p.amount), 0) as ledger_balance
+		from wallet_accounts as wa
+		left join postings as p on p.account_id = wa.id
+		group by wa.id
+		having wa.balance <> coalesce(sum(p.amount), 0)
+		order by wa.id;
+	`
+	err = tx.SelectContext(ctx, &report.MismatchedAccounts, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "check account balances")
+	}
+
+	query = `
+		select wa.id as account_id, wa.currency, wa.balance
+		from wallet_accounts as wa
+		join wallets as w on w.id = wa.wallet_id
+		where not w.system and wa.balance < 0
+		order by wa.id;
+	`
+	err = tx.SelectContext(ctx, &report.OverdrawnAccounts, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "check overdrawn accounts")
+	}
+
+	report.OK = len(report.UnbalancedEntries) == 0 &&
+		len(report.MismatchedAccounts) == 0 &&
+		len(report.OverdrawnAccounts) == 0
+
+	return report, nil
+}
@@ -0,0 +1,252 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+type Kind string
+
+const (
+	Deposit    Kind = "deposit"
+	Withdrawal Kind = "withdrawal"
+	Transfer   Kind = "transfer"
+	Exchange   Kind = "exchange"
+)
+
+// System wallets keep the other side of money entering or leaving user wallets.
+const (
+	ExternalWallet string = "system:external"
+	ExchangeWallet string = "system:exchange"
+)
+
+var (
+	ErrAccountNotFound   = errors.New("account not found")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrUnbalancedEntry   = errors.New("entry postings do not sum to zero")
+	ErrInvalidAmount     = errors.New("invalid amount")
+)
+
+// Currencies tells the number of decimal places amounts in a currency are kept with,
+// the currency catalogue provides it.
+type Currencies interface {
+	MinorUnits(code currency_helpers.CurrencyCode) int32
+}
+
+// Posting moves Amount into (positive) or out of (negative) the account.
+type Posting struct {
+	ID        int64                         `json:"id" db:"id"`
+	EntryID   int64                         `json:"entryId" db:"entry_id"`
+	AccountID int64                         `json:"accountId" db:"account_id"`
+	Currency  currency_helpers.CurrencyCode `json:"currency" db:"currency"`
+	Amount    currency_helpers.Decimal      `json:"amount" db:"amount"`
+}
+
+// Entry is a journal entry which postings sum to zero in every currency.
+type Entry struct {
+	ID          int64     `json:"id" db:"id"`
+	Kind        Kind      `json:"kind" db:"kind"`
+	Description string    `json:"description" db:"description"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+	Postings    []Posting `json:"postings" db:"-"`
+}
+
+// Post writes the entry with its postings and applies them to the account balances.
+// It must be called inside a transaction so that a failed check rolls everything back.
+func Post(ctx context.Context, tx *sqlx.Tx, currencies Currencies, entry *Entry) error {
+	err := validate(currencies, entry)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		insert into journal_entries (kind, description) values ($1, $2)
+		returning id, created_at;
+	`
+	err = tx.QueryRowxContext(ctx, query, entry.Kind, entry.Description).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return errors.Wrap(err, "insert journal entry")
+	}
+
+	// lock accounts in the same order everywhere to avoid deadlocks
+	postings := make([]*Posting, 0, len(entry.Postings))
+	for i := range entry.Postings {
+		postings = append(postings, &entry.Postings[i])
+	}
+	sort.SliceStable(postings, func(i, j int) bool {
+		return postings[i].AccountID < postings[j].AccountID
+	})
+
+	for _, posting := range postings {
+		posting.EntryID = entry.ID
+
+		// wallet_accounts_balance_check keeps balances of user accounts non-negative
+		balanceQuery := `
+			update wallet_accounts
+			set balance = balance + $1
+			where id = $2 and currency = $3
+			returning id;
+		`
+		var accountID int64
+		err = tx.QueryRowxContext(ctx, balanceQuery, posting.Amount, posting.AccountID, posting.Currency).Scan(&accountID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrAccountNotFound
+			}
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23514" {
+				return ErrInsufficientFunds
+			}
+			return errors.Wrap(err, "update account balance")
+		}
+
+		postingQuery := `
+			insert into postings (entry_id, account_id, currency, amount) values ($1, $2, $3, $4)
+			returning id;
+		`
+		err = tx.QueryRowxContext(
+			ctx, postingQuery, posting.EntryID, posting.AccountID, posting.Currency, posting.Amount,
+		).Scan(&posting.ID)
+		if err != nil {
+			return errors.Wrap(err, "insert posting")
+		}
+	}
+
+	return nil
+}
+
+func validate(currencies Currencies, entry *Entry) error {
+	if len(entry.Postings) < 2 {
+		return errors.Wrap(ErrUnbalancedEntry, "entry needs at least two postings")
+	}
+
+	sums := make(map[currency_helpers.CurrencyCode]currency_helpers.Decimal)
+	for _, posting := range entry.Postings {
+		amount := currency_helpers.NewMoney(posting.Amount, posting.Currency, currencies.MinorUnits(posting.Currency))
+		if amount.IsZero() || !amount.IsExact() {
+			return errors.Wrapf(ErrInvalidAmount, "posting %s", amount)
+		}
+
+		sums[posting.Currency] = sums[posting.Currency].Add(posting.Amount)
+	}
+
+	for currency, sum := range sums {
+		if !sum.IsZero() {
+			return errors.Wrapf(ErrUnbalancedEntry, "currency '%s'", currency)
+		}
+	}
+
+	return nil
+}
+
+// AccountID returns the id of the wallet account in the currency.
+func AccountID(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	walletID int64,
+	currency currency_helpers.CurrencyCode,
+) (int64, error) {
+	query := `
+		select wa.id
+		from wallet_accounts as wa
+		where wa.wallet_id = $1 and wa.currency = $2;
+	`
+	var accountID int64
+	err := tx.GetContext(ctx, &accountID, query, walletID, currency)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrAccountNotFound
+		}
+		return 0, errors.Wrap(err, "get account")
+	}
+
+	return accountID, nil
+}
+
+// SystemAccountID returns the account of the system wallet in the currency, opening it if needed.
+func SystemAccountID(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	systemWallet string,
+	currency currency_helpers.CurrencyCode,
+) (int64, error) {
+	query := `
+		insert into wallet_accounts (wallet_id, currency)
+		select w.id, $2
+		from wallets as w
+		where w.user_id = $1 and w.system
+		on conflict (wallet_id, currency) do update set currency = excluded.currency
+		returning id;
+	`
+	var accountID int64
+	err := tx.GetContext(ctx, &accountID, query, systemWallet, currency)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, errors.Errorf("system wallet '%s' not found", systemWallet)
+		}
+		return 0, errors.Wrap(err, "get system account")
+	}
+
+	return accountID, nil
+}
+
+// WalletEntries returns journal entries touching any account of the wallet, newest first.
+func WalletEntries(ctx context.Context, db *sqlx.DB, walletID int64, limit int) ([]Entry, error) {
+	query := `
+		select je.id, je.kind, je.description, je.created_at
+		from journal_entries as je
+		where exists (
+			select 1
+			from postings as p
+			join wallet_accounts as wa on wa.id = p.account_id
+			where p.entry_id = je.id and wa.wallet_id = $1
+		)
+		order by je.id desc
+		limit $2;
+	`
+	entries := []Entry{}
+	err := db.SelectContext(ctx, &entries, query, walletID, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "get journal entries")
+	}
+	if len(entries) == 0 {
+		return entries, nil
+	}
+
+	entryIDs := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		entryIDs = append(entryIDs, entry.ID)
+	}
+
+	postingsQuery, params, err := sqlx.In(`
+		select p.id, p.entry_id, p.account_id, p.currency, p.amount
+		from postings as p
+		where p.entry_id in (?)
+		order by p.id;
+	`, entryIDs)
+	if err != nil {
+		return nil, errors.Wrap(err, "prepare postings query")
+	}
+
+	var postings []Posting
+	err = db.SelectContext(ctx, &postings, db.Rebind(postingsQuery), params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "get postings")
+	}
+
+	byEntry := make(map[int64][]Posting, len(entries))
+	for _, posting := range postings {
+		byEntry[posting.EntryID] = append(byEntry[posting.EntryID], posting)
+	}
+	for i := range entries {
+		entries[i].Postings = byEntry[entries[i].ID]
+	}
+
+	return entries, nil
+}
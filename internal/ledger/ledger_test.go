@@ -0,0 +1,403 @@
+package ledger
+
+import (
+	"context"
+	"os"
+	"testing"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// minorUnits stands in for the currency catalogue.
+type minorUnits map[currency_helpers.CurrencyCode]int32
+
+func (m minorUnits) MinorUnits(code currency_helpers.CurrencyCode) int32 {
+	return m[code]
+}
+
+var currencies = minorUnits{"USD": 2, "JPY": 0, "BTC": 8}
+
+func decimal(t *testing.T, s string) currency_helpers.Decimal {
+	t.Helper()
+	d, err := currency_helpers.ParseDecimal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		postings []Posting
+		err      error
+	}{
+		{
+			name: "balanced",
+			postings: []Posting{
+				{AccountID: 1, Currency: "USD", Amount: decimal(t, "10.50")},
+				{AccountID: 2, Currency: "USD", Amount: decimal(t, "-10.50")},
+			},
+		},
+		{
+			name: "balanced in every currency",
+			postings: []Posting{
+				{AccountID: 1, Currency: "USD", Amount: decimal(t, "-10")},
+				{AccountID: 2, Currency: "USD", Amount: decimal(t, "10")},
+				{AccountID: 3, Currency: "BTC", Amount: decimal(t, "0.00012345")},
+				{AccountID: 4, Currency: "BTC", Amount: decimal(t, "-0.00012345")},
+			},
+		},
+		{
+			name:     "single posting",
+			postings: []Posting{{AccountID: 1, Currency: "USD", Amount: decimal(t, "10")}},
+			err:      ErrUnbalancedEntry,
+		},
+		{
+			name: "unbalanced",
+			postings: []Posting{
+				{AccountID: 1, Currency: "USD", Amount: decimal(t, "10")},
+				{AccountID: 2, Currency: "USD", Amount: decimal(t, "-9.99")},
+			},
+			err: ErrUnbalancedEntry,
+		},
+		{
+			name: "unbalanced in one currency",
+			postings: []Posting{
+				{AccountID: 1, Currency: "USD", Amount: decimal(t, "10")},
+				{AccountID: 2, Currency: "JPY", Amount: decimal(t, "-10")},
+			},
+			err: ErrUnbalancedEntry,
+		},
+		{
+			name: "zero amount",
+			postings: []Posting{
+				{AccountID: 1, Currency: "USD", Amount: decimal(t, "0")},
+				{AccountID: 2, Currency: "USD", Amount: decimal(t, "0")},
+			},
+			err: ErrInvalidAmount,
+		},
+		{
+			name: "beyond minor units",
+			postings: []Posting{
+				{AccountID: 1, Currency: "JPY", Amount: decimal(t, "10.5")},
+				{AccountID: 2, Currency: "JPY", Amount: decimal(t, "-10.5")},
+			},
+			err: ErrInvalidAmount,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate(currencies, &Entry{Kind: Transfer, Postings: tt.postings})
+			if !errors.Is(err, tt.err) {
+				t.Errorf("got error %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestPostRejectsUnbalancedEntry(t *testing.T) {
+	entry := &Entry{
+		Kind: Deposit,
+		Postings: []Posting{
+			{AccountID: 1, Currency: "USD", Amount: decimal(t, "10")},
+			{AccountID: 2, Currency: "USD", Amount: decimal(t, "-5")},
+		},
+	}
+
+	// the entry is rejected before anything is written, so no transaction is needed
+	err := Post(context.Background(), nil, currencies, entry)
+	if !errors.Is(err, ErrUnbalancedEntry) {
+		t.Fatalf("got error %v, want %v", err, ErrUnbalancedEntry)
+	}
+	if entry.ID != 0 {
+		t.Errorf("entry got id %d", entry.ID)
+	}
+}
+
+// testDB connects to the database in TEST_DATABASE_URL and migrates it, tests using it are skipped without one.
+// Every test works with its own wallets, so the database may be shared with other data.
+func testDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	url, ok := os.LookupEnv("TEST_DATABASE_URL")
+	if !ok {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sqlx.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	driver, err := postgres.WithInstance(db.DB, &postgres.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrate.NewWithDatabaseInstance("file://../../migrations", "postgres", driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.Up()
+	if err != nil && err != migrate.ErrNoChange {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+type fixture struct {
+	db       *sqlx.DB
+	wallet   int64
+	account  int64
+	external int64
+	entries  []int64
+}
+
+// newFixture opens a user wallet with a USD account, removed with its entries after the test.
+func newFixture(t *testing.T, db *sqlx.DB) *fixture {
+	t.Helper()
+	ctx := context.Background()
+	f := &fixture{db: db}
+
+	err := db.GetContext(ctx, &f.wallet, "insert into wallets (user_id) values ($1) returning id;", "test:"+t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.GetContext(ctx, &f.account,
+		"insert into wallet_accounts (wallet_id, currency) values ($1, 'USD') returning id;", f.wallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.inTx(t, func(tx *sqlx.Tx) error {
+		f.external, err = SystemAccountID(ctx, tx, ExternalWallet, "USD")
+		return err
+	})
+
+	t.Cleanup(func() {
+		ctx := context.Background()
+		// the shared system account is put back to its balance before the test
+		_, err := db.ExecContext(ctx, "update wallet_accounts set balance = balance - $1 where id = $2;",
+			f.postedTo(t, f.external), f.external)
+		if err != nil {
+			t.Error(err)
+		}
+		for _, query := range []string{
+			"delete from postings where entry_id = any($1);",
+			"delete from journal_entries where id = any($1);",
+		} {
+			_, err = db.ExecContext(ctx, query, pq.Array(f.entries))
+			if err != nil {
+				t.Error(err)
+			}
+		}
+		_, err = db.ExecContext(ctx, "delete from wallets where id = $1;", f.wallet)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	return f
+}
+
+func (f *fixture) inTx(t *testing.T, fn func(tx *sqlx.Tx) error) {
+	t.Helper()
+	tx, err := f.db.BeginTxx(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	err = fn(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// post moves amount from the external wallet into the account, a negative amount moves it out.
+func (f *fixture) post(ctx context.Context, amount currency_helpers.Decimal) error {
+	tx, err := f.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	entry := &Entry{
+		Kind: Deposit,
+		Postings: []Posting{
+			{AccountID: f.account, Currency: "USD", Amount: amount},
+			{AccountID: f.external, Currency: "USD", Amount: amount.Neg()},
+		},
+	}
+	err = Post(ctx, tx, currencies, entry)
+	if err != nil {
+		return err
+	}
+	f.entries = append(f.entries, entry.ID)
+
+	return tx.Commit()
+}
+
+// postedTo sums the amounts the fixture entries posted to the account.
+func (f *fixture) postedTo(t *testing.T, account int64) currency_helpers.Decimal {
+	t.Helper()
+	var sum currency_helpers.Decimal
+	err := f.db.GetContext(context.Background(), &sum,
+		"select coalesce(sum(amount), 0) from postings where entry_id = any($1) and account_id = $2;",
+		pq.Array(f.entries), account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sum
+}
+
+func (f *fixture) balance(t *testing.T) currency_helpers.Decimal {
+	t.Helper()
+	var balance currency_helpers.Decimal
+	err := f.db.GetContext(context.Background(), &balance, "select balance from wallet_accounts where id = $1;", f.account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return balance
+}
+
+func TestPostRejectsOverdraft(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t, testDB(t))
+
+	err := f.post(ctx, decimal(t, "100"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = f.post(ctx, decimal(t, "-100.01"))
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("got error %v, want %v", err, ErrInsufficientFunds)
+	}
+	if balance := f.balance(t); !balance.Equal(decimal(t, "100")) {
+		t.Errorf("balance %s after the rejected entry, want 100", balance)
+	}
+
+	// the system side may go negative, the user one down to zero
+	err = f.post(ctx, decimal(t, "-100"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance := f.balance(t); !balance.IsZero() {
+		t.Errorf("balance %s, want 0", balance)
+	}
+}
+
+// TestBalanceCheck keeps user balances non-negative in the database, the system flag is copied from the wallet.
+func TestBalanceCheck(t *testing.T) {
+	ctx := context.Background()
+	db := testDB(t)
+	f := newFixture(t, db)
+
+	for account, want := range map[int64]bool{f.account: false, f.external: true} {
+		var system bool
+		err := db.GetContext(ctx, &system, "select system from wallet_accounts where id = $1;", account)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if system != want {
+			t.Errorf("account %d: got system %v, want %v", account, system, want)
+		}
+	}
+
+	_, err := db.ExecContext(ctx, "update wallet_accounts set balance = -1 where id = $1;", f.account)
+	if pqErr, ok := err.(*pq.Error); !ok || pqErr.Constraint != "wallet_accounts_balance_check" {
+		t.Errorf("got error %v, want a violation of the balance check", err)
+	}
+	// the flag follows the wallet, it cannot be set on the account to get around the check
+	_, err = db.ExecContext(ctx, "update wallet_accounts set system = true, balance = -1 where id = $1;", f.account)
+	if err == nil {
+		t.Error("user account overdrawn by setting the system flag")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	db := testDB(t)
+	f := newFixture(t, db)
+
+	err := f.post(ctx, decimal(t, "50"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Check(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, account := range report.MismatchedAccounts {
+		if account.AccountID == f.account {
+			t.Errorf("balanced account %d reported as mismatched", f.account)
+		}
+	}
+
+	// break every invariant around the fixture account: an entry posting to one side only,
+	// and a balance neither matching the postings nor allowed for a user account
+	var entryID int64
+	err = db.GetContext(ctx, &entryID, "insert into journal_entries (kind) values ('deposit') returning id;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.entries = append(f.entries, entryID)
+	_, err = db.ExecContext(ctx,
+		"insert into postings (entry_id, account_id, currency, amount) values ($1, $2, 'USD', 5);", entryID, f.account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.ExecContext(ctx, "update wallet_accounts set balance = -1 where id = $1;", f.account)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err = Check(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OK {
+		t.Error("report is ok")
+	}
+
+	unbalanced := false
+	for _, entry := range report.UnbalancedEntries {
+		if entry.EntryID == entryID {
+			unbalanced = entry.Currency == "USD" && entry.Sum.Equal(decimal(t, "5"))
+		}
+	}
+	if !unbalanced {
+		t.Errorf("entry %d summing to 5 USD not reported in %+v", entryID, report.UnbalancedEntries)
+	}
+
+	mismatched := false
+	for _, account := range report.MismatchedAccounts {
+		if account.AccountID == f.account {
+			mismatched = account.Balance.Equal(decimal(t, "-1")) && account.LedgerBalance.Equal(decimal(t, "55"))
+		}
+	}
+	if !mismatched {
+		t.Errorf("account %d with balance -1 and postings of 55 not reported in %+v", f.account, report.MismatchedAccounts)
+	}
+
+	overdrawn := false
+	for _, account := range report.OverdrawnAccounts {
+		overdrawn = overdrawn || account.AccountID == f.account
+	}
+	if !overdrawn {
+		t.Errorf("account %d not reported as overdrawn in %+v", f.account, report.OverdrawnAccounts)
+	}
+}
@@ -0,0 +1,50 @@
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+)
+
+// RoutePattern normalises a chi route pattern to the form used as a document path,
+// so routes registered as "/" inside a subrouter match their path without the trailing slash.
+func RoutePattern(pattern string) string {
+	if len(pattern) > 1 {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	return pattern
+}
+
+// CheckRoutes reports routes of the router missing from the document and documented operations without a route.
+func (d *Document) CheckRoutes(routes chi.Routes) error {
+	registered := make(map[string]bool)
+	problems := make([]string, 0)
+	err := chi.Walk(routes, func(method string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		route = RoutePattern(route)
+		registered[strings.ToLower(method)+" "+route] = true
+		if _, ok := d.Operation(method, route); !ok {
+			problems = append(problems, "undocumented route "+method+" "+route)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "walk routes")
+	}
+
+	for path, item := range d.Paths {
+		for method := range *item {
+			if !registered[method+" "+path] {
+				problems = append(problems, "documented route without handler "+strings.ToUpper(method)+" "+path)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrBodyTooLarge is returned by ValidateRequest when the body is cut off by http.MaxBytesReader.
+var ErrBodyTooLarge = errors.New("request body is too large")
+
+// FieldError describes a part of the request not matching the operation.
+type FieldError struct {
+	In      string `json:"in"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidateRequest checks parameters and the body of the request against the operation,
+// pathParam returns the value of a path parameter. The body is read and put back for the handler.
+func (d *Document) ValidateRequest(
+	operation *Operation,
+	r *http.Request,
+	pathParam func(name string) string,
+) ([]FieldError, error) {
+	result := make([]FieldError, 0)
+
+	query := r.URL.Query()
+	for _, param := range operation.Parameters {
+		var (
+			value   string
+			present bool
+		)
+		switch param.In {
+		case "path":
+			value = pathParam(param.Name)
+			present = value != ""
+		case "query":
+			value = query.Get(param.Name)
+			present = query.Has(param.Name)
+		case "header":
+			value = r.Header.Get(param.Name)
+			present = value != ""
+		default:
+			continue
+		}
+
+		if !present {
+			if param.Required {
+				result = append(result, FieldError{In: param.In, Field: param.Name, Message: "is required"})
+			}
+			continue
+		}
+		for _, message := range d.validateParam(param.Schema, value) {
+			result = append(result, FieldError{In: param.In, Field: param.Name, Message: message})
+		}
+	}
+
+	if operation.RequestBody == nil {
+		return result, nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, errors.Wrapf(ErrBodyTooLarge, "over %d bytes", tooLarge.Limit)
+		}
+		return nil, errors.Wrap(err, "read request body")
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if operation.RequestBody.Required {
+			result = append(result, FieldError{In: "body", Message: "is required"})
+		}
+		return result, nil
+	}
+
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || mediaType != "application/json" {
+			result = append(result, FieldError{
+				In: "header", Field: "Content-Type", Message: "must be application/json",
+			})
+			return result, nil
+		}
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	err = decoder.Decode(&value)
+	if err != nil {
+		result = append(result, FieldError{In: "body", Message: "invalid JSON: " + err.Error()})
+		return result, nil
+	}
+
+	schema := operation.RequestBody.Content["application/json"].Schema
+	for _, fieldErr := range d.validate(schema, value, "") {
+		fieldErr.In = "body"
+		result = append(result, fieldErr)
+	}
+
+	return result, nil
+}
+
+// validateParam converts the raw parameter to the schema type before validating it.
+func (d *Document) validateParam(schema *Schema, raw string) []string {
+	schema = d.resolve(schema)
+	if schema == nil {
+		return nil
+	}
+
+	var value interface{} = raw
+	switch schema.Type {
+	case "integer", "number":
+		value = json.Number(raw)
+	case "boolean":
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return []string{"must be a boolean"}
+		}
+		value = parsed
+	}
+
+	messages := make([]string, 0)
+	for _, fieldErr := range d.validate(schema, value, "") {
+		messages = append(messages, fieldErr.Message)
+	}
+	return messages
+}
+
+func (d *Document) validate(schema *Schema, value interface{}, field string) []FieldError {
+	schema = d.resolve(schema)
+	if schema == nil {
+		return nil
+	}
+	fail := func(format string, args ...interface{}) []FieldError {
+		return []FieldError{{Field: field, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	if value == nil {
+		if schema.Nullable || schema.Type == "" && len(schema.OneOf) == 0 {
+			return nil
+		}
+		return fail("must not be null")
+	}
+
+	if len(schema.OneOf) > 0 {
+		matched := 0
+		for _, option := range schema.OneOf {
+			if len(d.validate(option, value, field)) == 0 {
+				matched++
+			}
+		}
+		if matched != 1 {
+			return fail("must match exactly one of %s", describeOneOf(d, schema.OneOf))
+		}
+		return nil
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fail("must be an object")
+		}
+		return d.validateObject(schema, object, field)
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fail("must be an array")
+		}
+		if len(items) < schema.MinItems {
+			return fail("must have at least %d items", schema.MinItems)
+		}
+		result := make([]FieldError, 0)
+		for i, item := range items {
+			result = append(result, d.validate(schema.Items, item, fmt.Sprintf("%s[%d]", field, i))...)
+		}
+		return result
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fail("must be a string")
+		}
+		return validateString(schema, str, field)
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			return fail("must be a number")
+		}
+		parsed, err := number.Float64()
+		if err != nil {
+			return fail("must be a number")
+		}
+		if schema.Type == "integer" {
+			if _, err := number.Int64(); err != nil {
+				return fail("must be an integer")
+			}
+		}
+		if schema.Minimum != nil && parsed < *schema.Minimum {
+			return fail("must be at least %v", *schema.Minimum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fail("must be a boolean")
+		}
+	}
+
+	return nil
+}
+
+func (d *Document) validateObject(schema *Schema, object map[string]interface{}, field string) []FieldError {
+	result := make([]FieldError, 0)
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			result = append(result, FieldError{Field: joinField(field, name), Message: "is required"})
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property, ok := schema.Properties[name]
+		if !ok {
+			property = schema.AdditionalProperties
+		}
+		result = append(result, d.validate(property, object[name], joinField(field, name))...)
+	}
+
+	return result
+}
+
+func validateString(schema *Schema, value string, field string) []FieldError {
+	fail := func(message string) []FieldError {
+		return []FieldError{{Field: field, Message: message}}
+	}
+
+	if len(schema.Enum) > 0 {
+		found := false
+		for _, option := range schema.Enum {
+			found = found || option == value
+		}
+		if !found {
+			return fail("must be one of " + strings.Join(schema.Enum, ", "))
+		}
+	}
+	if schema.Pattern != "" && !compile(schema.Pattern).MatchString(value) {
+		return fail("must match " + schema.Pattern)
+	}
+
+	switch schema.Format {
+	case "date":
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return fail("must be a date like 2006-01-02")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return fail("must be an RFC 3339 date-time")
+		}
+	}
+
+	return nil
+}
+
+func describeOneOf(d *Document, options []*Schema) string {
+	types := make([]string, 0, len(options))
+	for _, option := range options {
+		types = append(types, d.resolve(option).Type)
+	}
+	return strings.Join(types, ", ")
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+var (
+	patternsMu sync.Mutex
+	patterns   = make(map[string]*regexp.Regexp)
+)
+
+// compile caches schema patterns, they come from the document built in code, so a bad one is a programming error.
+func compile(pattern string) *regexp.Regexp {
+	patternsMu.Lock()
+	defer patternsMu.Unlock()
+	re, ok := patterns[pattern]
+	if !ok {
+		re = regexp.MustCompile(pattern)
+		patterns[pattern] = re
+	}
+	return re
+}
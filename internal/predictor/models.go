@@ -0,0 +1,274 @@
+package predictor
+
+import (
+	"context"
+	"math"
+	"time"
+	"wallet-service/internal/currency_helpers"
+)
+
+const (
+	// confidenceZ is the normal quantile of the confidenceLevel intervals the models estimate.
+	confidenceZ     = 1.959964
+	confidenceLevel = 0.95
+
+	defaultMovingAverageWindow = 7
+
+	// maxSlope keeps the regressed returns mean reverting, with a slope of 1 or more
+	// the compounded returns grow without bound and the rates overflow.
+	maxSlope = 0.99
+)
+
+// MovingAverage predicts the mean of the last window rates for every day.
+// The interval is the spread of its one day errors on the history, widening as a random walk.
+type MovingAverage struct {
+	horizon int
+	window  int
+}
+
+func NewMovingAverage(horizon, window int) *MovingAverage {
+	return &MovingAverage{
+		horizon: horizon,
+		window:  window,
+	}
+}
+
+func (m *MovingAverage) Name() string {
+	return MovingAverageModel
+}
+
+func (m *MovingAverage) Version() string {
+	return "1"
+}
+
+func (m *MovingAverage) Predict(
+	_ context.Context,
+	history map[currency_helpers.CustomTime]currency_helpers.Decimal,
+	from time.Time,
+) (*currency_helpers.Forecast, error) {
+	values := series(history)
+	if len(values) <= m.window {
+		return nil, ErrNotEnoughHistory
+	}
+
+	errs := make([]float64, 0, len(values)-m.window)
+	for i := m.window; i < len(values); i++ {
+		errs = append(errs, values[i]-mean(values[i-m.window:i]))
+	}
+	sigma := rootMeanSquare(errs)
+	forecast := mean(values[len(values)-m.window:])
+
+	points := make([]point, 0, m.horizon)
+	for h := 1; h <= m.horizon; h++ {
+		spread := confidenceZ * sigma * math.Sqrt(float64(h))
+		points = append(points, point{forecast, forecast - spread, forecast + spread})
+	}
+	return datedForecast(m, history, points, from)
+}
+
+// Holt is double exponential smoothing with a linear trend, the smoothing factors
+// are chosen on a grid by the error of one day forecasts on the history.
+type Holt struct {
+	horizon int
+}
+
+func NewHolt(horizon int) *Holt {
+	return &Holt{horizon: horizon}
+}
+
+func (m *Holt) Name() string {
+	return HoltModel
+}
+
+func (m *Holt) Version() string {
+	return "1"
+}
+
+func (m *Holt) Predict(
+	_ context.Context,
+	history map[currency_helpers.CustomTime]currency_helpers.Decimal,
+	from time.Time,
+) (*currency_helpers.Forecast, error) {
+	values := series(history)
+	if len(values) < 3 {
+		return nil, ErrNotEnoughHistory
+	}
+
+	bestAlpha, bestBeta, bestFit := 0.0, 0.0, holtFit{sse: math.Inf(1)}
+	for alpha := 0.05; alpha < 1; alpha += 0.05 {
+		for beta := 0.05; beta < 1; beta += 0.05 {
+			fit := fitHolt(values, alpha, beta)
+			if fit.sse < bestFit.sse {
+				bestAlpha, bestBeta, bestFit = alpha, beta, fit
+			}
+		}
+	}
+	sigma := math.Sqrt(bestFit.sse / float64(len(values)-2))
+
+	points := make([]point, 0, m.horizon)
+	for h := 1; h <= m.horizon; h++ {
+		// the variance of the h day forecast of Holt's method with additive errors
+		variance := 1.0
+		for j := 1; j < h; j++ {
+			variance += math.Pow(bestAlpha*(1+float64(j)*bestBeta), 2)
+		}
+		forecast := bestFit.level + float64(h)*bestFit.trend
+		spread := confidenceZ * sigma * math.Sqrt(variance)
+		points = append(points, point{forecast, forecast - spread, forecast + spread})
+	}
+	return datedForecast(m, history, points, from)
+}
+
+type holtFit struct {
+	level, trend, sse float64
+}
+
+func fitHolt(values []float64, alpha, beta float64) holtFit {
+	level, trend := values[0], values[1]-values[0]
+	sse := 0.0
+	for _, value := range values[1:] {
+		err := value - (level + trend)
+		sse += err * err
+		previous := level
+		level = alpha*value + (1-alpha)*(level+trend)
+		trend = beta*(level-previous) + (1-beta)*trend
+	}
+	return holtFit{level: level, trend: trend, sse: sse}
+}
+
+// LinearRegression regresses daily log returns on the previous day ones and compounds
+// the predicted returns, so the rate cannot turn negative and the interval is skewed upwards.
+type LinearRegression struct {
+	horizon int
+}
+
+func NewLinearRegression(horizon int) *LinearRegression {
+	return &LinearRegression{horizon: horizon}
+}
+
+func (m *LinearRegression) Name() string {
+	return LinearRegressionModel
+}
+
+func (m *LinearRegression) Version() string {
+	return "1"
+}
+
+func (m *LinearRegression) Predict(
+	_ context.Context,
+	history map[currency_helpers.CustomTime]currency_helpers.Decimal,
+	from time.Time,
+) (*currency_helpers.Forecast, error) {
+	values := series(history)
+	returns := make([]float64, 0, len(values))
+	for i := 1; i < len(values); i++ {
+		if values[i-1] <= 0 || values[i] <= 0 {
+			continue
+		}
+		returns = append(returns, math.Log(values[i]/values[i-1]))
+	}
+	if len(returns) < 3 {
+		return nil, ErrNotEnoughHistory
+	}
+
+	intercept, slope := leastSquares(returns[:len(returns)-1], returns[1:])
+	slope = math.Max(-maxSlope, math.Min(slope, maxSlope))
+	residuals := make([]float64, 0, len(returns)-1)
+	for i := 1; i < len(returns); i++ {
+		residuals = append(residuals, returns[i]-(intercept+slope*returns[i-1]))
+	}
+	sigma := rootMeanSquare(residuals)
+
+	logRate := math.Log(values[len(values)-1])
+	lastReturn := returns[len(returns)-1]
+	// weights[j] is the effect of the error j days ago on the log rate through the compounded returns
+	weights := make([]float64, 0, m.horizon)
+	points := make([]point, 0, m.horizon)
+	for h := 1; h <= m.horizon; h++ {
+		lastReturn = intercept + slope*lastReturn
+		logRate += lastReturn
+
+		weights = append(weights, 0)
+		variance := 0.0
+		for j := range weights {
+			weights[j] = weights[j]*slope + 1
+			variance += weights[j] * weights[j]
+		}
+		spread := confidenceZ * sigma * math.Sqrt(variance)
+		points = append(points, point{math.Exp(logRate), math.Exp(logRate - spread), math.Exp(logRate + spread)})
+	}
+	return datedForecast(m, history, points, from)
+}
+
+type point struct {
+	rate, lower, upper float64
+}
+
+// datedForecast describes the points the model made from the history dated day by day starting at from.
+func datedForecast(
+	p Predictor,
+	history map[currency_helpers.CustomTime]currency_helpers.Decimal,
+	points []point,
+	from time.Time,
+) (*currency_helpers.Forecast, error) {
+	for _, point := range points {
+		for _, value := range []float64{point.rate, point.lower, point.upper} {
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				return nil, ErrNotFinite
+			}
+		}
+	}
+	return newForecast(p, history, datePoints(points, from)), nil
+}
+
+// datePoints dates model forecasts day by day starting at from, lower bounds are kept non-negative.
+// The points have to be finite.
+func datePoints(points []point, from time.Time) []currency_helpers.PredictedRate {
+	values := make([]currency_helpers.Decimal, 0, len(points))
+	for _, p := range points {
+		values = append(values, currency_helpers.NewDecimalFromFloat(p.rate))
+	}
+
+	result := byDay(values, from)
+	for i, p := range points {
+		lower := currency_helpers.NewDecimalFromFloat(math.Max(p.lower, 0))
+		upper := currency_helpers.NewDecimalFromFloat(p.upper)
+		result[i].Lower = &lower
+		result[i].Upper = &upper
+	}
+	return result
+}
+
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+func rootMeanSquare(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, value := range values {
+		sum += value * value
+	}
+	return math.Sqrt(sum / float64(len(values)))
+}
+
+// leastSquares fits y = intercept + slope*x.
+func leastSquares(x, y []float64) (float64, float64) {
+	meanX, meanY := mean(x), mean(y)
+	covariance, variance := 0.0, 0.0
+	for i := range x {
+		covariance += (x[i] - meanX) * (y[i] - meanY)
+		variance += (x[i] - meanX) * (x[i] - meanX)
+	}
+	if variance == 0 {
+		return meanY, 0
+	}
+	slope := covariance / variance
+	return meanY - slope*meanX, slope
+}
@@ -0,0 +1,241 @@
+package predictor
+
+import (
+	"context"
+	"encoding/csv"
+	"math"
+	"os"
+	"testing"
+	"time"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	testHorizon = 30
+	// backtestHorizon is how many days are predicted from every backtest origin
+	backtestHorizon = 14
+)
+
+// loadRates reads a date,rate series from testdata. eur_usd.csv is a year of daily rates
+// generated as a random walk with slightly autocorrelated returns around 1.1, a stand-in for a real pair.
+func loadRates(t *testing.T, name string) []historyPoint {
+	t.Helper()
+
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	points := make([]historyPoint, 0, len(records))
+	for _, record := range records[1:] {
+		date, err := time.Parse(currency_helpers.CustomTimeLayout, record[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		rate, err := currency_helpers.ParseDecimal(record[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		points = append(points, historyPoint{date, rate})
+	}
+	return points
+}
+
+type historyPoint struct {
+	date time.Time
+	rate currency_helpers.Decimal
+}
+
+func toHistory(points []historyPoint) map[currency_helpers.CustomTime]currency_helpers.Decimal {
+	history := make(map[currency_helpers.CustomTime]currency_helpers.Decimal, len(points))
+	for _, p := range points {
+		history[currency_helpers.CustomTime{Time: p.date}] = p.rate
+	}
+	return history
+}
+
+// accelerating multiplies the rate by growth, squaring growth every day. Growing returns fit a regression slope
+// above 1, which made the compounded returns overflow, a growth of 1 gives a flat series without any error.
+func accelerating(days int, growth float64) []historyPoint {
+	points := make([]historyPoint, 0, days)
+	rate := 1.0
+	for i := 0; i < days; i++ {
+		points = append(points, historyPoint{
+			date: time.Date(2023, 1, 1+i, 0, 0, 0, 0, time.UTC),
+			rate: currency_helpers.NewDecimalFromFloat(rate),
+		})
+		rate *= growth
+		growth *= growth
+	}
+	return points
+}
+
+// TestModelsBacktest forecasts the second half of the fixture from rolling origins,
+// each model trained on all the rates before its origin, and checks the errors and bounds over all of them.
+func TestModelsBacktest(t *testing.T) {
+	rates := loadRates(t, "eur_usd.csv")
+
+	tests := []struct {
+		model Predictor
+		// maxMAPE is the mean absolute percentage error allowed over all the predicted days
+		maxMAPE float64
+		// minCoverage is the share of realized rates that have to fall within the 95% bounds
+		minCoverage float64
+	}{
+		{NewMovingAverage(backtestHorizon, defaultMovingAverageWindow), 1.5, 0.85},
+		{NewHolt(backtestHorizon), 1.5, 0.85},
+		{NewLinearRegression(backtestHorizon), 1.5, 0.85},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model.Name(), func(t *testing.T) {
+			errorSum, covered, predicted := 0.0, 0, 0
+			for origin := len(rates) / 2; origin+backtestHorizon <= len(rates); origin += backtestHorizon {
+				train, actual := rates[:origin], rates[origin:origin+backtestHorizon]
+				forecast, err := tt.model.Predict(context.Background(), toHistory(train), actual[0].date)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if forecast.Model != tt.model.Name() || forecast.ConfidenceLevel != confidenceLevel {
+					t.Errorf("forecast model %q confidence %v", forecast.Model, forecast.ConfidenceLevel)
+				}
+				if !forecast.TrainingStart.Equal(train[0].date) || !forecast.TrainingEnd.Equal(train[len(train)-1].date) {
+					t.Errorf("training period %s - %s", forecast.TrainingStart, forecast.TrainingEnd)
+				}
+				if len(forecast.Points) != backtestHorizon {
+					t.Fatalf("got %d points, want %d", len(forecast.Points), backtestHorizon)
+				}
+
+				for i, point := range forecast.Points {
+					if !point.Date.Equal(actual[i].date) {
+						t.Fatalf("point %d is dated %s, want %s", i, point.Date, actual[i].date)
+					}
+					if point.Lower == nil || point.Upper == nil {
+						t.Fatalf("point %d has no bounds", i)
+					}
+					if point.Lower.GreaterThan(point.Rate) || point.Upper.LessThan(point.Rate) {
+						t.Errorf("point %d rate %s is outside of its bounds [%s, %s]", i, point.Rate, point.Lower, point.Upper)
+					}
+
+					realized := actual[i].rate.InexactFloat64()
+					errorSum += math.Abs(point.Rate.InexactFloat64()-realized) / realized
+					if !actual[i].rate.LessThan(*point.Lower) && !actual[i].rate.GreaterThan(*point.Upper) {
+						covered++
+					}
+					predicted++
+				}
+			}
+
+			mape := errorSum / float64(predicted) * 100
+			if mape > tt.maxMAPE {
+				t.Errorf("MAPE %.3f%% exceeds %.1f%%", mape, tt.maxMAPE)
+			}
+			coverage := float64(covered) / float64(predicted)
+			if coverage < tt.minCoverage {
+				t.Errorf("bounds cover %.0f%% of the realized rates, want at least %.0f%%", coverage*100, tt.minCoverage*100)
+			}
+			t.Logf("MAPE %.3f%%, coverage %.0f%% over %d days", mape, coverage*100, predicted)
+		})
+	}
+}
+
+func TestModelsNotEnoughHistory(t *testing.T) {
+	rates := loadRates(t, "eur_usd.csv")
+
+	tests := []struct {
+		model Predictor
+		// days is the longest history the model refuses
+		days int
+	}{
+		{NewMovingAverage(testHorizon, defaultMovingAverageWindow), defaultMovingAverageWindow},
+		{NewHolt(testHorizon), 2},
+		{NewLinearRegression(testHorizon), 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model.Name(), func(t *testing.T) {
+			for _, days := range []int{0, tt.days} {
+				_, err := tt.model.Predict(context.Background(), toHistory(rates[:days]), time.Now())
+				if !errors.Is(err, ErrNotEnoughHistory) {
+					t.Errorf("%d days: got error %v, want %v", days, err, ErrNotEnoughHistory)
+				}
+			}
+
+			_, err := tt.model.Predict(context.Background(), toHistory(rates[:tt.days+1]), time.Now())
+			if err != nil {
+				t.Errorf("%d days: %s", tt.days+1, err)
+			}
+		})
+	}
+}
+
+func TestModelsExplosiveHistory(t *testing.T) {
+	tests := []struct {
+		name    string
+		history []historyPoint
+	}{
+		{"accelerating", accelerating(6, 3)},
+		{"flat", accelerating(30, 1)},
+		{"collapsing", accelerating(20, 0.5)},
+	}
+	for _, model := range []Predictor{
+		NewMovingAverage(testHorizon, 3),
+		NewHolt(testHorizon),
+		NewLinearRegression(testHorizon),
+	} {
+		for _, tt := range tests {
+			t.Run(model.Name()+"/"+tt.name, func(t *testing.T) {
+				forecast, err := model.Predict(context.Background(), toHistory(tt.history), time.Now())
+				if err != nil {
+					if !errors.Is(err, ErrNotFinite) && !errors.Is(err, ErrNotEnoughHistory) {
+						t.Fatalf("unexpected error %v", err)
+					}
+					return
+				}
+				if len(forecast.Points) != testHorizon {
+					t.Fatalf("got %d points, want %d", len(forecast.Points), testHorizon)
+				}
+				for i, point := range forecast.Points {
+					if point.Lower.IsNegative() {
+						t.Errorf("point %d lower bound %s is negative", i, point.Lower)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestModelsGet(t *testing.T) {
+	models := &Models{
+		predictors:   map[string]Predictor{HoltModel: NewHolt(1)},
+		defaultModel: HoltModel,
+	}
+
+	tests := []struct {
+		name  string
+		model string
+		ok    bool
+	}{
+		{"default", "", true},
+		{"by name", HoltModel, true},
+		{"unknown", "arima", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := models.Get(tt.model)
+			if ok != tt.ok {
+				t.Fatalf("got ok %v, want %v", ok, tt.ok)
+			}
+			if ok && p.Name() != HoltModel {
+				t.Errorf("got model %s", p.Name())
+			}
+		})
+	}
+}
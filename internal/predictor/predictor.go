@@ -0,0 +1,145 @@
+package predictor
+
+import (
+	"context"
+	"sort"
+	"time"
+	"wallet-service/internal/config"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/pkg/errors"
+)
+
+// Predictor forecasts daily rates of a currency pair from its history.
+type Predictor interface {
+	// Name identifies the model in requests, logs and cache keys.
+	Name() string
+	// Version changes whenever the model starts predicting differently from the same history.
+	Version() string
+	// Predict returns the forecast of rates for consecutive days starting at from.
+	Predict(
+		ctx context.Context,
+		history map[currency_helpers.CustomTime]currency_helpers.Decimal,
+		from time.Time,
+	) (*currency_helpers.Forecast, error)
+}
+
+const (
+	RemotePredictor       string = "remote"
+	MovingAverageModel    string = "moving_average"
+	HoltModel             string = "holt"
+	LinearRegressionModel string = "linear_regression"
+)
+
+// ModelNames lists the models a request may choose, remote only when the remote predictor is configured.
+var ModelNames = []string{RemotePredictor, MovingAverageModel, HoltModel, LinearRegressionModel}
+
+var (
+	ErrNotEnoughHistory = errors.New("not enough history to predict")
+	ErrNotFinite        = errors.New("predicted rates are not finite")
+)
+
+// Models holds the predictors by name and the one used when a request does not choose a model.
+type Models struct {
+	predictors   map[string]Predictor
+	defaultModel string
+}
+
+// InitModels returns nil when predictions are disabled, time series are then served without them.
+func InitModels(cfg *config.Config) (*Models, error) {
+	if !cfg.PredictorEnabled {
+		return nil, nil
+	}
+	if cfg.PredictorHorizon <= 0 {
+		return nil, errors.New("predictor horizon must be positive")
+	}
+
+	m := &Models{
+		predictors:   make(map[string]Predictor),
+		defaultModel: cfg.PredictorModel,
+	}
+	for _, p := range []Predictor{
+		NewMovingAverage(cfg.PredictorHorizon, defaultMovingAverageWindow),
+		NewHolt(cfg.PredictorHorizon),
+		NewLinearRegression(cfg.PredictorHorizon),
+	} {
+		m.predictors[p.Name()] = p
+	}
+	if cfg.PredictorURL != "" {
+		m.predictors[RemotePredictor] = NewRemote(cfg.PredictorURL, cfg.PredictorTimeout)
+	}
+
+	if _, ok := m.predictors[m.defaultModel]; !ok {
+		return nil, errors.Errorf("unknown default prediction model '%s'", m.defaultModel)
+	}
+
+	return m, nil
+}
+
+// Get returns the predictor of the model, the default one for an empty name.
+func (m *Models) Get(name string) (Predictor, bool) {
+	if name == "" {
+		name = m.defaultModel
+	}
+	p, ok := m.predictors[name]
+	return p, ok
+}
+
+// byDay dates predicted values day by day starting at from.
+func byDay(values []currency_helpers.Decimal, from time.Time) []currency_helpers.PredictedRate {
+	year, month, day := from.Date()
+	result := make([]currency_helpers.PredictedRate, 0, len(values))
+	for i, value := range values {
+		result = append(result, currency_helpers.PredictedRate{
+			Date: currency_helpers.CustomTime{Time: time.Date(year, month, day+i, 0, 0, 0, 0, time.UTC)},
+			Rate: value,
+		})
+	}
+	return result
+}
+
+// newForecast describes points the predictor made from the history.
+func newForecast(
+	p Predictor,
+	history map[currency_helpers.CustomTime]currency_helpers.Decimal,
+	points []currency_helpers.PredictedRate,
+) *currency_helpers.Forecast {
+	forecast := &currency_helpers.Forecast{
+		Model:        p.Name(),
+		ModelVersion: p.Version(),
+		GeneratedAt:  time.Now().UTC(),
+		Points:       points,
+	}
+	if dates := historyDates(history); len(dates) > 0 {
+		forecast.TrainingStart = dates[0]
+		forecast.TrainingEnd = dates[len(dates)-1]
+	}
+	for _, point := range points {
+		if point.Lower != nil {
+			forecast.ConfidenceLevel = confidenceLevel
+			break
+		}
+	}
+	return forecast
+}
+
+// series orders the history by date, the models treat it as evenly spaced.
+func series(history map[currency_helpers.CustomTime]currency_helpers.Decimal) []float64 {
+	dates := historyDates(history)
+	result := make([]float64, 0, len(dates))
+	for _, date := range dates {
+		result = append(result, history[date].InexactFloat64())
+	}
+	return result
+}
+
+func historyDates(history map[currency_helpers.CustomTime]currency_helpers.Decimal) []currency_helpers.CustomTime {
+	dates := make([]currency_helpers.CustomTime, 0, len(history))
+	for date := range history {
+		dates = append(dates, date)
+	}
+	sort.Slice(dates, func(i, j int) bool {
+		return dates[i].Before(dates[j].Time)
+	})
+	return dates
+}
@@ -0,0 +1,79 @@
+package predictor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/pkg/errors"
+)
+
+// Remote is a Predictor backed by an HTTP service, which takes the history as a JSON object
+// of dates to rates and answers with a JSON array of rates for the following days.
+type Remote struct {
+	url     string
+	timeout time.Duration
+	client  *http.Client
+}
+
+func NewRemote(url string, timeout time.Duration) *Remote {
+	return &Remote{
+		url:     url,
+		timeout: timeout,
+		client:  http.DefaultClient,
+	}
+}
+
+func (p *Remote) Name() string {
+	return RemotePredictor
+}
+
+// Version is empty, the remote service does not report which model answered.
+func (p *Remote) Version() string {
+	return ""
+}
+
+func (p *Remote) Predict(
+	ctx context.Context,
+	history map[currency_helpers.CustomTime]currency_helpers.Decimal,
+	from time.Time,
+) (*currency_helpers.Forecast, error) {
+	// the predictor expects plain JSON numbers
+	rates := make(map[currency_helpers.CustomTime]float64, len(history))
+	for t, rate := range history {
+		rates[t] = rate.InexactFloat64()
+	}
+	data, err := json.Marshal(rates)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal predictor request")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "prepare predictor request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "request predictions")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	var predicted []currency_helpers.Decimal
+	err = json.NewDecoder(resp.Body).Decode(&predicted)
+	if err != nil {
+		return nil, errors.Wrap(err, "read predictor response")
+	}
+
+	return newForecast(p, history, byDay(predicted, from)), nil
+}
@@ -0,0 +1,337 @@
+package rates
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding/charmap"
+)
+
+const (
+	cbrRequestDateLayout  = "02/01/2006"
+	cbrResponseDateLayout = "02.01.2006"
+	rubCode               = currency_helpers.CurrencyCode("RUB")
+)
+
+// CBR is a RateProvider backed by the Central Bank of Russia XML API.
+// The bank quotes every currency in rubles per Nominal units,
+// so rates for any other base are calculated as cross rates through RUB.
+type CBR struct {
+	url     string
+	timeout time.Duration
+	client  *http.Client
+}
+
+func NewCBR(url string, timeout time.Duration) *CBR {
+	return &CBR{
+		url:     url,
+		timeout: timeout,
+		client:  http.DefaultClient,
+	}
+}
+
+type cbrDailyResponse struct {
+	XMLName xml.Name    `xml:"ValCurs"`
+	Date    string      `xml:"Date,attr"`
+	Valutes []cbrValute `xml:"Valute"`
+}
+
+type cbrValute struct {
+	ID       string `xml:"ID,attr"`
+	CharCode string `xml:"CharCode"`
+	Nominal  string `xml:"Nominal"`
+	Value    string `xml:"Value"`
+}
+
+type cbrDynamicResponse struct {
+	XMLName xml.Name    `xml:"ValCurs"`
+	ID      string      `xml:"ID,attr"`
+	Records []cbrRecord `xml:"Record"`
+}
+
+type cbrRecord struct {
+	Date    string `xml:"Date,attr"`
+	Nominal string `xml:"Nominal"`
+	Value   string `xml:"Value"`
+}
+
+func (c *CBR) Name() string {
+	return CBRProvider
+}
+
+func (c *CBR) Latest(
+	ctx context.Context,
+	base currency_helpers.CurrencyCode,
+) (*currency_helpers.CurrencyRates, error) {
+	daily, err := c.daily(ctx, fmt.Sprintf("%s/XML_daily.asp", c.url))
+	if err != nil {
+		return nil, err
+	}
+
+	return daily.toCurrencyRates(base, c.Name())
+}
+
+func (c *CBR) ForDate(
+	ctx context.Context,
+	base currency_helpers.CurrencyCode,
+	date time.Time,
+) (*currency_helpers.CurrencyRates, error) {
+	daily, err := c.daily(ctx, fmt.Sprintf("%s/XML_daily.asp?date_req=%s", c.url, date.Format(cbrRequestDateLayout)))
+	if err != nil {
+		return nil, err
+	}
+
+	return daily.toCurrencyRates(base, c.Name())
+}
+
+func (c *CBR) TimeSeries(
+	ctx context.Context,
+	base currency_helpers.CurrencyCode,
+	second currency_helpers.CurrencyCode,
+	start time.Time,
+	end time.Time,
+) (*currency_helpers.CurrencyTimelineRates, error) {
+	// dynamic rates are requested by the internal CBR currency id, not by its code
+	daily, err := c.daily(ctx, fmt.Sprintf("%s/XML_daily.asp?date_req=%s", c.url, end.Format(cbrRequestDateLayout)))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[currency_helpers.CurrencyCode]string, len(daily.Valutes))
+	for _, valute := range daily.Valutes {
+		ids[currency_helpers.CurrencyCode(valute.CharCode)] = valute.ID
+	}
+
+	baseSeries, err := c.rubSeries(ctx, ids, base, start, end)
+	if err != nil {
+		return nil, err
+	}
+	secondSeries, err := c.rubSeries(ctx, ids, second, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	dates := make(map[currency_helpers.CustomTime]struct{})
+	if baseSeries == nil && secondSeries == nil {
+		// the ruble against itself has no series, its rate is 1 on every day of the period
+		for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+			dates[currency_helpers.CustomTime{Time: date}] = struct{}{}
+		}
+	}
+	for date := range baseSeries {
+		dates[date] = struct{}{}
+	}
+	for date := range secondSeries {
+		dates[date] = struct{}{}
+	}
+
+	rates := make(map[currency_helpers.CustomTime]map[currency_helpers.CurrencyCode]currency_helpers.Decimal, len(dates))
+	for date := range dates {
+		baseRate, ok := seriesValue(baseSeries, date)
+		if !ok {
+			continue
+		}
+		secondRate, ok := seriesValue(secondSeries, date)
+		if !ok {
+			continue
+		}
+
+		rates[date] = map[currency_helpers.CurrencyCode]currency_helpers.Decimal{
+			second: currency_helpers.DivideRate(baseRate, secondRate),
+		}
+	}
+
+	return &currency_helpers.CurrencyTimelineRates{
+		Base:      base,
+		Rates:     rates,
+		StartDate: currency_helpers.CustomTime{Time: start},
+		EndDate:   currency_helpers.CustomTime{Time: end},
+		Source:    c.Name(),
+	}, nil
+}
+
+// rubSeries returns rubles per one unit of the currency by date.
+// It returns nil for RUB itself, since its rate is always 1.
+func (c *CBR) rubSeries(
+	ctx context.Context,
+	ids map[currency_helpers.CurrencyCode]string,
+	currencyCode currency_helpers.CurrencyCode,
+	start time.Time,
+	end time.Time,
+) (map[currency_helpers.CustomTime]currency_helpers.Decimal, error) {
+	if currencyCode == rubCode {
+		return nil, nil
+	}
+
+	id, ok := ids[currencyCode]
+	if !ok {
+		return nil, errors.Errorf("currency '%s' is not quoted by CBR", currencyCode)
+	}
+
+	dynamic := &cbrDynamicResponse{}
+	err := c.get(
+		ctx,
+		fmt.Sprintf(
+			"%s/XML_dynamic.asp?date_req1=%s&date_req2=%s&VAL_NM_RQ=%s",
+			c.url,
+			start.Format(cbrRequestDateLayout),
+			end.Format(cbrRequestDateLayout),
+			id,
+		),
+		dynamic,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	series := make(map[currency_helpers.CustomTime]currency_helpers.Decimal, len(dynamic.Records))
+	for _, record := range dynamic.Records {
+		date, err := time.Parse(cbrResponseDateLayout, record.Date)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse CBR record date '%s'", record.Date)
+		}
+
+		rate, err := rubPerUnit(record.Nominal, record.Value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse CBR record for '%s'", currencyCode)
+		}
+
+		series[currency_helpers.CustomTime{Time: date}] = rate
+	}
+
+	return series, nil
+}
+
+func seriesValue(
+	series map[currency_helpers.CustomTime]currency_helpers.Decimal,
+	date currency_helpers.CustomTime,
+) (currency_helpers.Decimal, bool) {
+	if series == nil {
+		return currency_helpers.NewDecimalFromInt(1), true
+	}
+
+	rate, ok := series[date]
+	return rate, ok
+}
+
+func (c *CBR) daily(ctx context.Context, url string) (*cbrDailyResponse, error) {
+	daily := &cbrDailyResponse{}
+	err := c.get(ctx, url, daily)
+	if err != nil {
+		return nil, err
+	}
+
+	return daily, nil
+}
+
+func (d *cbrDailyResponse) toCurrencyRates(
+	base currency_helpers.CurrencyCode,
+	source string,
+) (*currency_helpers.CurrencyRates, error) {
+	date, err := time.Parse(cbrResponseDateLayout, d.Date)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse CBR rates date '%s'", d.Date)
+	}
+
+	rubRates := make(map[currency_helpers.CurrencyCode]currency_helpers.Decimal, len(d.Valutes)+1)
+	rubRates[rubCode] = currency_helpers.NewDecimalFromInt(1)
+	for _, valute := range d.Valutes {
+		rate, err := rubPerUnit(valute.Nominal, valute.Value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse CBR rate for '%s'", valute.CharCode)
+		}
+		rubRates[currency_helpers.CurrencyCode(valute.CharCode)] = rate
+	}
+
+	baseRate, ok := rubRates[base]
+	if !ok {
+		return nil, errors.Errorf("currency '%s' is not quoted by CBR", base)
+	}
+
+	rates := make(map[currency_helpers.CurrencyCode]currency_helpers.Decimal, len(rubRates))
+	for currencyCode, rate := range rubRates {
+		rates[currencyCode] = currency_helpers.DivideRate(baseRate, rate)
+	}
+
+	return &currency_helpers.CurrencyRates{
+		Base:   base,
+		Rates:  rates,
+		Date:   currency_helpers.CustomTime{Time: date},
+		Source: source,
+	}, nil
+}
+
+// rubPerUnit converts CBR quote of rubles per nominal units into rubles per one unit.
+func rubPerUnit(nominal, value string) (currency_helpers.Decimal, error) {
+	n, err := parseCBRNumber(nominal)
+	if err != nil {
+		return currency_helpers.Zero, errors.Wrap(err, "parse nominal")
+	}
+	if !n.IsPositive() {
+		return currency_helpers.Zero, errors.New("non-positive nominal")
+	}
+
+	v, err := parseCBRNumber(value)
+	if err != nil {
+		return currency_helpers.Zero, errors.Wrap(err, "parse value")
+	}
+	if !v.IsPositive() {
+		return currency_helpers.Zero, errors.New("non-positive value")
+	}
+
+	return currency_helpers.DivideRate(v, n), nil
+}
+
+// parseCBRNumber parses numbers in the CBR format with comma as a decimal separator.
+func parseCBRNumber(s string) (currency_helpers.Decimal, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", ".")
+	return currency_helpers.ParseDecimal(s)
+}
+
+func (c *CBR) get(ctx context.Context, url string, dst interface{}) error {
+	reqCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrap(err, "error in prepare request")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "error in get new data")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	decoder := xml.NewDecoder(resp.Body)
+	decoder.CharsetReader = cbrCharsetReader
+	err = decoder.Decode(dst)
+	if err != nil {
+		return errors.Wrap(err, "internal error in read XML data")
+	}
+
+	return nil
+}
+
+// cbrCharsetReader decodes windows-1251 documents which CBR serves by default.
+func cbrCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "windows-1251", "cp1251":
+		return charmap.Windows1251.NewDecoder().Reader(input), nil
+	case "utf-8", "utf8":
+		return input, nil
+	default:
+		return nil, errors.Errorf("unsupported charset '%s'", charset)
+	}
+}
@@ -0,0 +1,256 @@
+package rates
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+	"wallet-service/internal/currency_helpers"
+)
+
+// newTestCBR serves the windows-1251 testdata documents the way the bank does,
+// dynamic rates by the internal currency id, and records the requested URLs.
+func newTestCBR(t *testing.T) (*CBR, *[]string) {
+	t.Helper()
+
+	requested := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.String())
+
+		name := ""
+		switch r.URL.Path {
+		case "/XML_daily.asp":
+			name = "cbr_daily.xml"
+		case "/XML_dynamic.asp":
+			name = map[string]string{
+				"R01235": "cbr_dynamic_usd.xml",
+				"R01820": "cbr_dynamic_jpy.xml",
+			}[r.URL.Query().Get("VAL_NM_RQ")]
+		}
+		if name == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		body, err := os.ReadFile("testdata/" + name)
+		if err != nil {
+			t.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml; charset=windows-1251")
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+
+	return NewCBR(server.URL, time.Second), &requested
+}
+
+func decimal(t *testing.T, s string) currency_helpers.Decimal {
+	t.Helper()
+	d, err := currency_helpers.ParseDecimal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func day(d int) time.Time {
+	return time.Date(2023, 3, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestCBRForDate(t *testing.T) {
+	tests := []struct {
+		name string
+		base currency_helpers.CurrencyCode
+		// want holds the rates of the base, the 100 JPY and 100 KZT nominals scaled down to one unit
+		want map[currency_helpers.CurrencyCode]string
+	}{
+		{
+			name: "ruble",
+			base: "RUB",
+			want: map[currency_helpers.CurrencyCode]string{
+				"RUB": "1", "USD": "0.0125", "EUR": "0.01", "JPY": "2", "KZT": "6.25",
+			},
+		},
+		{
+			name: "cross through the ruble",
+			base: "USD",
+			want: map[currency_helpers.CurrencyCode]string{
+				"RUB": "80", "USD": "1", "EUR": "0.8", "JPY": "160", "KZT": "500",
+			},
+		},
+		{
+			name: "nominal base",
+			base: "JPY",
+			want: map[currency_helpers.CurrencyCode]string{
+				"RUB": "0.5", "USD": "0.00625", "EUR": "0.005", "JPY": "1", "KZT": "3.125",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cbr, requested := newTestCBR(t)
+
+			rates, err := cbr.ForDate(context.Background(), tt.base, day(2))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if want := "/XML_daily.asp?date_req=02/03/2023"; len(*requested) != 1 || (*requested)[0] != want {
+				t.Errorf("requested %v, want %s", *requested, want)
+			}
+			if rates.Base != tt.base || rates.Source != CBRProvider || !rates.Date.Equal(day(2)) {
+				t.Errorf("got base %s source %s date %s", rates.Base, rates.Source, rates.Date)
+			}
+			if len(rates.Rates) != len(tt.want) {
+				t.Errorf("got rates %v, want %v", rates.Rates, tt.want)
+			}
+			for code, want := range tt.want {
+				if rate, ok := rates.Rates[code]; !ok || !rate.Equal(decimal(t, want)) {
+					t.Errorf("%s: got rate %s, want %s", code, rate, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCBRForDateUnknownBase(t *testing.T) {
+	cbr, _ := newTestCBR(t)
+
+	_, err := cbr.ForDate(context.Background(), "GBP", day(2))
+	if err == nil {
+		t.Error("got rates of a currency CBR does not quote")
+	}
+}
+
+func TestCBRTimeSeries(t *testing.T) {
+	tests := []struct {
+		name         string
+		base, second currency_helpers.CurrencyCode
+		// want holds the rates by day of March, days missing from a series are skipped
+		want map[int]string
+	}{
+		{"cross through the ruble", "USD", "JPY", map[int]string{1: "160", 3: "150"}},
+		{"reverse cross", "JPY", "USD", map[int]string{1: "0.00625", 3: "0.006666666666666667"}},
+		{"ruble base", "RUB", "USD", map[int]string{1: "0.0125", 2: "0.012422360248447205", 3: "0.012345679012345679"}},
+		{"ruble second", "USD", "RUB", map[int]string{1: "80", 2: "80.5", 3: "81"}},
+		{"ruble to itself", "RUB", "RUB", map[int]string{1: "1", 2: "1", 3: "1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cbr, _ := newTestCBR(t)
+
+			series, err := cbr.TimeSeries(context.Background(), tt.base, tt.second, day(1), day(3))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if series.Base != tt.base || series.Source != CBRProvider {
+				t.Errorf("got base %s source %s", series.Base, series.Source)
+			}
+			if len(series.Rates) != len(tt.want) {
+				t.Errorf("got %d days %v, want %d", len(series.Rates), series.Rates, len(tt.want))
+			}
+			for d, want := range tt.want {
+				rates, ok := series.Rates[currency_helpers.CustomTime{Time: day(d)}]
+				if !ok {
+					t.Errorf("March %d is missing", d)
+					continue
+				}
+				if rate := rates[tt.second]; !rate.Equal(decimal(t, want)) {
+					t.Errorf("March %d: got rate %s, want %s", d, rate, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCBRTimeSeriesRequests(t *testing.T) {
+	cbr, requested := newTestCBR(t)
+
+	_, err := cbr.TimeSeries(context.Background(), "USD", "JPY", day(1), day(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"/XML_daily.asp?date_req=03/03/2023",
+		"/XML_dynamic.asp?date_req1=01/03/2023&date_req2=03/03/2023&VAL_NM_RQ=R01235",
+		"/XML_dynamic.asp?date_req1=01/03/2023&date_req2=03/03/2023&VAL_NM_RQ=R01820",
+	}
+	if len(*requested) != len(want) {
+		t.Fatalf("requested %v, want %v", *requested, want)
+	}
+	for i := range want {
+		if (*requested)[i] != want[i] {
+			t.Errorf("request %d: got %s, want %s", i, (*requested)[i], want[i])
+		}
+	}
+}
+
+func TestCBRErrors(t *testing.T) {
+	cbr, _ := newTestCBR(t)
+
+	// EUR is quoted daily but has no dynamic document on the test server
+	_, err := cbr.TimeSeries(context.Background(), "USD", "EUR", day(1), day(3))
+	if err == nil {
+		t.Error("got a time series the server did not serve")
+	}
+	_, err = cbr.TimeSeries(context.Background(), "USD", "GBP", day(1), day(3))
+	if err == nil {
+		t.Error("got a time series of a currency CBR does not quote")
+	}
+}
+
+func TestParseCBRNumber(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"75,4323", "75.4323", true},
+		{" 1 ", "1", true},
+		{"0,0123", "0.0123", true},
+		{"1.5", "1.5", true},
+		{"", "", false},
+		{"1 234,5", "", false},
+	}
+	for _, tt := range tests {
+		got, err := parseCBRNumber(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("%q: got error %v", tt.in, err)
+			continue
+		}
+		if tt.ok && !got.Equal(decimal(t, tt.want)) {
+			t.Errorf("%q: got %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRubPerUnit(t *testing.T) {
+	tests := []struct {
+		nominal, value string
+		want           string
+		ok             bool
+	}{
+		{"1", "80,0000", "80", true},
+		{"100", "50,0000", "0.5", true},
+		{"10", "12,3450", "1.2345", true},
+		{"0", "50,0000", "", false},
+		{"100", "0", "", false},
+		{"-1", "50,0000", "", false},
+	}
+	for _, tt := range tests {
+		got, err := rubPerUnit(tt.nominal, tt.value)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s per %s: got error %v", tt.value, tt.nominal, err)
+			continue
+		}
+		if tt.ok && !got.Equal(decimal(t, tt.want)) {
+			t.Errorf("%s per %s: got %s, want %s", tt.value, tt.nominal, got, tt.want)
+		}
+	}
+}
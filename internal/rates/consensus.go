@@ -0,0 +1,264 @@
+package rates
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/pkg/errors"
+)
+
+// Consensus asks all providers at once and accepts only rates confirmed by several of them.
+// A rate deviating from the median of all answers by more than maxDeviation percent
+// is treated as an outlier and dropped; the accepted rates are averaged.
+type Consensus struct {
+	providers    []RateProvider
+	minSources   int
+	maxDeviation currency_helpers.Decimal
+}
+
+func NewConsensus(providers []RateProvider, minSources int, maxDeviation currency_helpers.Decimal) *Consensus {
+	if minSources > len(providers) {
+		minSources = len(providers)
+	}
+	if minSources < 1 {
+		minSources = 1
+	}
+
+	return &Consensus{
+		providers:    providers,
+		minSources:   minSources,
+		maxDeviation: maxDeviation,
+	}
+}
+
+func (c *Consensus) Name() string {
+	names := make([]string, 0, len(c.providers))
+	for _, provider := range c.providers {
+		names = append(names, provider.Name())
+	}
+	return "consensus(" + strings.Join(names, ",") + ")"
+}
+
+type sourcedRate struct {
+	source string
+	rate   currency_helpers.Decimal
+}
+
+func (c *Consensus) Latest(
+	ctx context.Context,
+	base currency_helpers.CurrencyCode,
+) (*currency_helpers.CurrencyRates, error) {
+	return c.currencyRates(ctx, base, func(provider RateProvider) (*currency_helpers.CurrencyRates, error) {
+		return provider.Latest(ctx, base)
+	})
+}
+
+func (c *Consensus) ForDate(
+	ctx context.Context,
+	base currency_helpers.CurrencyCode,
+	date time.Time,
+) (*currency_helpers.CurrencyRates, error) {
+	return c.currencyRates(ctx, base, func(provider RateProvider) (*currency_helpers.CurrencyRates, error) {
+		return provider.ForDate(ctx, base, date)
+	})
+}
+
+func (c *Consensus) currencyRates(
+	ctx context.Context,
+	base currency_helpers.CurrencyCode,
+	fetch func(provider RateProvider) (*currency_helpers.CurrencyRates, error),
+) (*currency_helpers.CurrencyRates, error) {
+	answers := make([]*currency_helpers.CurrencyRates, len(c.providers))
+	errs := c.ask(func(i int, provider RateProvider) error {
+		currencyRates, err := fetch(provider)
+		answers[i] = currencyRates
+		return err
+	})
+
+	// rates of different dates are not mixed, the date most providers answered for is taken
+	date := majorityDate(answers)
+	byCurrency := make(map[currency_helpers.CurrencyCode][]sourcedRate)
+	succeeded := 0
+	for i, answer := range answers {
+		if answer == nil {
+			continue
+		}
+		if !answer.Date.Equal(date.Time) {
+			errs = append(errs, fmt.Sprintf("%s: answered for %s, others for %s",
+				c.providers[i].Name(), answer.Date.Format(currency_helpers.CustomTimeLayout),
+				date.Format(currency_helpers.CustomTimeLayout)))
+			continue
+		}
+		succeeded++
+		for currencyCode, rate := range answer.Rates {
+			byCurrency[currencyCode] = append(byCurrency[currencyCode], sourcedRate{
+				source: answer.SourceOf(currencyCode),
+				rate:   rate,
+			})
+		}
+	}
+
+	if succeeded < c.minSources {
+		return nil, errors.Errorf("not enough rate providers answered: %s", strings.Join(errs, "; "))
+	}
+
+	result := &currency_helpers.CurrencyRates{
+		Base:    base,
+		Rates:   make(map[currency_helpers.CurrencyCode]currency_helpers.Decimal, len(byCurrency)),
+		Date:    date,
+		Source:  c.Name(),
+		Sources: make(map[currency_helpers.CurrencyCode]string, len(byCurrency)),
+	}
+	for currencyCode, candidates := range byCurrency {
+		rate, sources, ok := c.agree(candidates)
+		if !ok {
+			continue
+		}
+		result.Rates[currencyCode] = rate
+		result.Sources[currencyCode] = sources
+	}
+
+	return result, nil
+}
+
+func (c *Consensus) TimeSeries(
+	ctx context.Context,
+	base currency_helpers.CurrencyCode,
+	second currency_helpers.CurrencyCode,
+	start time.Time,
+	end time.Time,
+) (*currency_helpers.CurrencyTimelineRates, error) {
+	answers := make([]*currency_helpers.CurrencyTimelineRates, len(c.providers))
+	errs := c.ask(func(i int, provider RateProvider) error {
+		timelineRates, err := provider.TimeSeries(ctx, base, second, start, end)
+		answers[i] = timelineRates
+		return err
+	})
+
+	byDate := make(map[currency_helpers.CustomTime][]sourcedRate)
+	succeeded := 0
+	for _, answer := range answers {
+		if answer == nil {
+			continue
+		}
+		succeeded++
+		for date, rates := range answer.Rates {
+			rate, ok := rates[second]
+			if !ok {
+				continue
+			}
+			byDate[date] = append(byDate[date], sourcedRate{
+				source: answer.Source,
+				rate:   rate,
+			})
+		}
+	}
+
+	if succeeded < c.minSources {
+		return nil, errors.Errorf("not enough rate providers answered: %s", strings.Join(errs, "; "))
+	}
+
+	rates := make(map[currency_helpers.CustomTime]map[currency_helpers.CurrencyCode]currency_helpers.Decimal, len(byDate))
+	for date, candidates := range byDate {
+		rate, _, ok := c.agree(candidates)
+		if !ok {
+			continue
+		}
+		rates[date] = map[currency_helpers.CurrencyCode]currency_helpers.Decimal{second: rate}
+	}
+
+	return &currency_helpers.CurrencyTimelineRates{
+		Base:      base,
+		Rates:     rates,
+		StartDate: currency_helpers.CustomTime{Time: start},
+		EndDate:   currency_helpers.CustomTime{Time: end},
+		Source:    c.Name(),
+	}, nil
+}
+
+// majorityDate returns the date most of the answers are for, the latest one of equally common dates.
+func majorityDate(answers []*currency_helpers.CurrencyRates) currency_helpers.CustomTime {
+	counts := make(map[time.Time]int)
+	var result currency_helpers.CustomTime
+	for _, answer := range answers {
+		if answer == nil {
+			continue
+		}
+		date := answer.Date.UTC()
+		counts[date]++
+		if counts[date] > counts[result.UTC()] || counts[date] == counts[result.UTC()] && date.After(result.Time) {
+			result = currency_helpers.CustomTime{Time: date}
+		}
+	}
+	return result
+}
+
+// ask calls every provider concurrently and returns errors of the failed ones.
+func (c *Consensus) ask(call func(i int, provider RateProvider) error) []string {
+	errs := make([]error, len(c.providers))
+
+	wg := sync.WaitGroup{}
+	for i, provider := range c.providers {
+		wg.Add(1)
+		go func(i int, provider RateProvider) {
+			defer wg.Done()
+			errs[i] = call(i, provider)
+		}(i, provider)
+	}
+	wg.Wait()
+
+	result := make([]string, 0)
+	for i, err := range errs {
+		if err != nil {
+			result = append(result, errors.Wrap(err, c.providers[i].Name()).Error())
+		}
+	}
+	return result
+}
+
+// agree drops outliers and averages the rest of the rates.
+// It reports false if fewer than minSources rates are left.
+func (c *Consensus) agree(candidates []sourcedRate) (currency_helpers.Decimal, string, bool) {
+	if len(candidates) < c.minSources {
+		return currency_helpers.Zero, "", false
+	}
+
+	values := make([]currency_helpers.Decimal, 0, len(candidates))
+	for _, candidate := range candidates {
+		values = append(values, candidate.rate)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].LessThan(values[j])
+	})
+	median := values[len(values)/2]
+	if len(values)%2 == 0 {
+		median = values[len(values)/2-1].Add(values[len(values)/2]).Div(currency_helpers.NewDecimalFromInt(2))
+	}
+	if !median.IsPositive() {
+		return currency_helpers.Zero, "", false
+	}
+
+	sum := currency_helpers.Zero
+	sources := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		deviation := candidate.rate.Sub(median).Abs().Mul(currency_helpers.Hundred).Div(median)
+		if deviation.GreaterThan(c.maxDeviation) {
+			continue
+		}
+		sum = sum.Add(candidate.rate)
+		sources = append(sources, candidate.source)
+	}
+
+	if len(sources) < c.minSources {
+		return currency_helpers.Zero, "", false
+	}
+
+	sort.Strings(sources)
+	average := currency_helpers.DivideRate(sum, currency_helpers.NewDecimalFromInt(int64(len(sources))))
+	return average, strings.Join(sources, ","), true
+}
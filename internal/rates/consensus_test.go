@@ -0,0 +1,278 @@
+package rates
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/pkg/errors"
+)
+
+var errTimeout = errors.New("Client.Timeout exceeded while awaiting headers")
+
+// fakeProvider answers with fixed rates for every day, or fails with err after delay,
+// and counts the calls. The answers are dated by date, the requested day when it is zero.
+type fakeProvider struct {
+	name  string
+	rates map[currency_helpers.CurrencyCode]string
+	date  time.Time
+	err   error
+	delay time.Duration
+	calls int
+}
+
+func (p *fakeProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeProvider) Latest(ctx context.Context, base currency_helpers.CurrencyCode) (*currency_helpers.CurrencyRates, error) {
+	return p.ForDate(ctx, base, day(3))
+}
+
+func (p *fakeProvider) ForDate(
+	_ context.Context,
+	base currency_helpers.CurrencyCode,
+	date time.Time,
+) (*currency_helpers.CurrencyRates, error) {
+	p.calls++
+	time.Sleep(p.delay)
+	if p.err != nil {
+		return nil, p.err
+	}
+	if !p.date.IsZero() {
+		date = p.date
+	}
+
+	result := &currency_helpers.CurrencyRates{
+		Base:   base,
+		Rates:  make(map[currency_helpers.CurrencyCode]currency_helpers.Decimal, len(p.rates)),
+		Date:   currency_helpers.CustomTime{Time: date},
+		Source: p.name,
+	}
+	for code, rate := range p.rates {
+		d, err := currency_helpers.ParseDecimal(rate)
+		if err != nil {
+			return nil, err
+		}
+		result.Rates[code] = d
+	}
+	return result, nil
+}
+
+func (p *fakeProvider) TimeSeries(
+	ctx context.Context,
+	base currency_helpers.CurrencyCode,
+	second currency_helpers.CurrencyCode,
+	start time.Time,
+	end time.Time,
+) (*currency_helpers.CurrencyTimelineRates, error) {
+	rates := make(map[currency_helpers.CustomTime]map[currency_helpers.CurrencyCode]currency_helpers.Decimal)
+	for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+		currencyRates, err := p.ForDate(ctx, base, date)
+		if err != nil {
+			return nil, err
+		}
+		if rate, ok := currencyRates.Rates[second]; ok {
+			rates[currency_helpers.CustomTime{Time: date}] = map[currency_helpers.CurrencyCode]currency_helpers.Decimal{second: rate}
+		}
+	}
+	return &currency_helpers.CurrencyTimelineRates{
+		Base:      base,
+		Rates:     rates,
+		StartDate: currency_helpers.CustomTime{Time: start},
+		EndDate:   currency_helpers.CustomTime{Time: end},
+		Source:    p.name,
+	}, nil
+}
+
+func TestConsensusForDate(t *testing.T) {
+	tests := []struct {
+		name       string
+		providers  []*fakeProvider
+		minSources int
+		// want holds the accepted rates and their sources, currencies without consensus are left out
+		want        map[currency_helpers.CurrencyCode]string
+		wantSources map[currency_helpers.CurrencyCode]string
+		wantDate    time.Time
+		wantErr     bool
+	}{
+		{
+			name: "all agree",
+			providers: []*fakeProvider{
+				{name: "a", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.90"}},
+				{name: "b", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.91"}},
+				{name: "c", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.92"}},
+			},
+			minSources:  2,
+			want:        map[currency_helpers.CurrencyCode]string{"EUR": "0.91"},
+			wantSources: map[currency_helpers.CurrencyCode]string{"EUR": "a,b,c"},
+			wantDate:    day(2),
+		},
+		{
+			name: "outlier dropped",
+			providers: []*fakeProvider{
+				{name: "a", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.90"}},
+				{name: "b", rates: map[currency_helpers.CurrencyCode]string{"EUR": "1.20"}},
+				{name: "c", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.91"}},
+			},
+			minSources:  2,
+			want:        map[currency_helpers.CurrencyCode]string{"EUR": "0.905"},
+			wantSources: map[currency_helpers.CurrencyCode]string{"EUR": "a,c"},
+			wantDate:    day(2),
+		},
+		{
+			name: "too few left without the outlier",
+			providers: []*fakeProvider{
+				{name: "a", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.90", "JPY": "140"}},
+				{name: "b", rates: map[currency_helpers.CurrencyCode]string{"EUR": "1.20", "JPY": "141"}},
+				{name: "c", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.91", "JPY": "142"}},
+			},
+			minSources:  3,
+			want:        map[currency_helpers.CurrencyCode]string{"JPY": "141"},
+			wantSources: map[currency_helpers.CurrencyCode]string{"JPY": "a,b,c"},
+			wantDate:    day(2),
+		},
+		{
+			name: "sources by currency",
+			providers: []*fakeProvider{
+				{name: "a", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.90", "JPY": "140"}},
+				{name: "b", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.90", "KZT": "450"}},
+				{name: "c", rates: map[currency_helpers.CurrencyCode]string{"JPY": "140", "KZT": "450"}},
+			},
+			minSources: 2,
+			want:       map[currency_helpers.CurrencyCode]string{"EUR": "0.90", "JPY": "140", "KZT": "450"},
+			wantSources: map[currency_helpers.CurrencyCode]string{
+				"EUR": "a,b", "JPY": "a,c", "KZT": "b,c",
+			},
+			wantDate: day(2),
+		},
+		{
+			name: "failed and timed out providers",
+			providers: []*fakeProvider{
+				{name: "a", err: errors.New("connection refused")},
+				{name: "b", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.90"}},
+				{name: "c", err: errTimeout, delay: 10 * time.Millisecond},
+				{name: "d", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.90"}},
+			},
+			minSources:  2,
+			want:        map[currency_helpers.CurrencyCode]string{"EUR": "0.90"},
+			wantSources: map[currency_helpers.CurrencyCode]string{"EUR": "b,d"},
+			wantDate:    day(2),
+		},
+		{
+			name: "fewer answers than min sources",
+			providers: []*fakeProvider{
+				{name: "a", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.90"}},
+				{name: "b", err: errTimeout},
+				{name: "c", err: errTimeout},
+			},
+			minSources: 2,
+			wantErr:    true,
+		},
+		{
+			name: "min sources capped by the providers",
+			providers: []*fakeProvider{
+				{name: "a", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.90"}},
+				{name: "b", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.90"}},
+			},
+			minSources:  5,
+			want:        map[currency_helpers.CurrencyCode]string{"EUR": "0.90"},
+			wantSources: map[currency_helpers.CurrencyCode]string{"EUR": "a,b"},
+			wantDate:    day(2),
+		},
+		{
+			// a provider answering with the document of the previous business day is not mixed in
+			name: "majority date",
+			providers: []*fakeProvider{
+				{name: "a", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.89"}, date: day(1)},
+				{name: "b", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.90"}},
+				{name: "c", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.90"}},
+			},
+			minSources:  2,
+			want:        map[currency_helpers.CurrencyCode]string{"EUR": "0.90"},
+			wantSources: map[currency_helpers.CurrencyCode]string{"EUR": "b,c"},
+			wantDate:    day(2),
+		},
+		{
+			name: "no majority for the date",
+			providers: []*fakeProvider{
+				{name: "a", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.90"}, date: day(1)},
+				{name: "b", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.90"}},
+				{name: "c", err: errTimeout},
+			},
+			minSources: 2,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := make([]RateProvider, 0, len(tt.providers))
+			for _, provider := range tt.providers {
+				providers = append(providers, provider)
+			}
+			consensus := NewConsensus(providers, tt.minSources, decimal(t, "1.5"))
+
+			rates, err := consensus.ForDate(context.Background(), "USD", day(2))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got rates %+v, want an error", rates)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, provider := range tt.providers {
+				if provider.calls != 1 {
+					t.Errorf("%s called %d times", provider.name, provider.calls)
+				}
+			}
+			if rates.Base != "USD" || rates.Source != consensus.Name() || !rates.Date.Equal(tt.wantDate) {
+				t.Errorf("got base %s source %s date %s", rates.Base, rates.Source, rates.Date)
+			}
+			if len(rates.Rates) != len(tt.want) {
+				t.Errorf("got rates %v, want %v", rates.Rates, tt.want)
+			}
+			for code, want := range tt.want {
+				if rate, ok := rates.Rates[code]; !ok || !rate.Equal(decimal(t, want)) {
+					t.Errorf("%s: got rate %s, want %s", code, rate, want)
+				}
+				if source := rates.SourceOf(code); source != tt.wantSources[code] {
+					t.Errorf("%s: got sources %s, want %s", code, source, tt.wantSources[code])
+				}
+			}
+		})
+	}
+}
+
+func TestConsensusTimeSeries(t *testing.T) {
+	consensus := NewConsensus([]RateProvider{
+		&fakeProvider{name: "a", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.90"}},
+		&fakeProvider{name: "b", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.92"}},
+		&fakeProvider{name: "c", err: errTimeout},
+	}, 2, decimal(t, "1.5"))
+
+	series, err := consensus.TimeSeries(context.Background(), "USD", "EUR", day(1), day(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(series.Rates) != 3 {
+		t.Errorf("got %d days %v, want 3", len(series.Rates), series.Rates)
+	}
+	for d := 1; d <= 3; d++ {
+		rate := series.Rates[currency_helpers.CustomTime{Time: day(d)}]["EUR"]
+		if !rate.Equal(decimal(t, "0.91")) {
+			t.Errorf("March %d: got rate %s, want 0.91", d, rate)
+		}
+	}
+}
+
+func TestConsensusName(t *testing.T) {
+	consensus := NewConsensus([]RateProvider{&fakeProvider{name: "cbr"}, &fakeProvider{name: "exchangerate_host"}}, 2, decimal(t, "1.5"))
+	if name := consensus.Name(); name != "consensus(cbr,exchangerate_host)" {
+		t.Errorf("got name %s", name)
+	}
+}
@@ -0,0 +1,118 @@
+package rates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/pkg/errors"
+)
+
+// ExchangerateHost is a RateProvider backed by the exchangerate.host API.
+type ExchangerateHost struct {
+	url     string
+	timeout time.Duration
+	client  *http.Client
+}
+
+func NewExchangerateHost(url string, timeout time.Duration) *ExchangerateHost {
+	return &ExchangerateHost{
+		url:     url,
+		timeout: timeout,
+		client:  http.DefaultClient,
+	}
+}
+
+func (e *ExchangerateHost) Name() string {
+	return ExchangerateHostProvider
+}
+
+func (e *ExchangerateHost) Latest(
+	ctx context.Context,
+	base currency_helpers.CurrencyCode,
+) (*currency_helpers.CurrencyRates, error) {
+	return e.getRates(ctx, fmt.Sprintf("%s/latest?base=%s", e.url, base))
+}
+
+func (e *ExchangerateHost) ForDate(
+	ctx context.Context,
+	base currency_helpers.CurrencyCode,
+	date time.Time,
+) (*currency_helpers.CurrencyRates, error) {
+	return e.getRates(ctx, fmt.Sprintf("%s/%s?base=%s", e.url, date.Format("02.01.2006"), base))
+}
+
+func (e *ExchangerateHost) TimeSeries(
+	ctx context.Context,
+	base currency_helpers.CurrencyCode,
+	second currency_helpers.CurrencyCode,
+	start time.Time,
+	end time.Time,
+) (*currency_helpers.CurrencyTimelineRates, error) {
+	url := fmt.Sprintf(
+		"%s/timeseries?start_date=%s&end_date=%s&base=%s&symbols=%s",
+		e.url,
+		start.Format(currency_helpers.CustomTimeLayout),
+		end.Format(currency_helpers.CustomTimeLayout),
+		base,
+		second,
+	)
+
+	timelineRates := &currency_helpers.CurrencyTimelineRatesResponse{}
+	err := e.get(ctx, url, timelineRates)
+	if err != nil {
+		return nil, err
+	}
+
+	if !timelineRates.Success || timelineRates.CurrencyTimelineRates == nil {
+		return nil, errors.New("unsuccessful getting new rates")
+	}
+
+	timelineRates.Source = e.Name()
+	return timelineRates.CurrencyTimelineRates, nil
+}
+
+func (e *ExchangerateHost) getRates(ctx context.Context, url string) (*currency_helpers.CurrencyRates, error) {
+	currencyRates := &currency_helpers.CurrencyRatesResponse{}
+	err := e.get(ctx, url, currencyRates)
+	if err != nil {
+		return nil, err
+	}
+
+	if !currencyRates.Success || currencyRates.CurrencyRates == nil {
+		return nil, errors.New("unsuccessful getting new rates")
+	}
+
+	currencyRates.Source = e.Name()
+	return currencyRates.CurrencyRates, nil
+}
+
+func (e *ExchangerateHost) get(ctx context.Context, url string, dst interface{}) error {
+	reqCtx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrap(err, "error in prepare request")
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "error in get new data")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(dst)
+	if err != nil {
+		return errors.Wrap(err, "internal error in read JSON data")
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package rates
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"wallet-service/internal/currency_helpers"
+)
+
+// newTestExchangerateHost serves the body with the status on every path and records the requested URLs.
+func newTestExchangerateHost(t *testing.T, status int, body string, delay time.Duration) (*ExchangerateHost, *[]string) {
+	t.Helper()
+
+	// a request timing out on the client may still be served while the next one arrives
+	var mu sync.Mutex
+	requested := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.String())
+		mu.Unlock()
+		time.Sleep(delay)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return NewExchangerateHost(server.URL, 100*time.Millisecond), &requested
+}
+
+func TestExchangerateHostForDate(t *testing.T) {
+	host, requested := newTestExchangerateHost(t, http.StatusOK,
+		`{"success":true,"base":"USD","date":"2023-03-02","rates":{"EUR":0.8,"JPY":160,"RUB":"80.5"}}`, 0)
+
+	rates, err := host.ForDate(context.Background(), "USD", day(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "/02.03.2023?base=USD"; len(*requested) != 1 || (*requested)[0] != want {
+		t.Errorf("requested %v, want %s", *requested, want)
+	}
+	if rates.Base != "USD" || rates.Source != ExchangerateHostProvider || !rates.Date.Equal(day(2)) {
+		t.Errorf("got base %s source %s date %s", rates.Base, rates.Source, rates.Date)
+	}
+	want := map[currency_helpers.CurrencyCode]string{"EUR": "0.8", "JPY": "160", "RUB": "80.5"}
+	if len(rates.Rates) != len(want) {
+		t.Errorf("got rates %v, want %v", rates.Rates, want)
+	}
+	for code, rate := range want {
+		if got, ok := rates.Rates[code]; !ok || !got.Equal(decimal(t, rate)) {
+			t.Errorf("%s: got rate %s, want %s", code, got, rate)
+		}
+	}
+}
+
+func TestExchangerateHostTimeSeries(t *testing.T) {
+	host, requested := newTestExchangerateHost(t, http.StatusOK, `{"success":true,"base":"USD",`+
+		`"start_date":"2023-03-01","end_date":"2023-03-03",`+
+		`"rates":{"2023-03-01":{"EUR":0.8},"2023-03-03":{"EUR":0.81}}}`, 0)
+
+	series, err := host.TimeSeries(context.Background(), "USD", "EUR", day(1), day(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "/timeseries?start_date=2023-03-01&end_date=2023-03-03&base=USD&symbols=EUR"
+	if len(*requested) != 1 || (*requested)[0] != want {
+		t.Errorf("requested %v, want %s", *requested, want)
+	}
+	if series.Base != "USD" || series.Source != ExchangerateHostProvider {
+		t.Errorf("got base %s source %s", series.Base, series.Source)
+	}
+	wantRates := map[int]string{1: "0.8", 3: "0.81"}
+	if len(series.Rates) != len(wantRates) {
+		t.Errorf("got %d days %v, want %d", len(series.Rates), series.Rates, len(wantRates))
+	}
+	for d, rate := range wantRates {
+		if got := series.Rates[currency_helpers.CustomTime{Time: day(d)}]["EUR"]; !got.Equal(decimal(t, rate)) {
+			t.Errorf("March %d: got rate %s, want %s", d, got, rate)
+		}
+	}
+}
+
+func TestExchangerateHostErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		delay  time.Duration
+	}{
+		{"server error", http.StatusInternalServerError, `{"success":true,"base":"USD","rates":{}}`, 0},
+		{"rate limited", http.StatusTooManyRequests, `{"success":false}`, 0},
+		{"unsuccessful", http.StatusOK, `{"success":false,"error":{"code":101}}`, 0},
+		{"without rates", http.StatusOK, `{"success":true}`, 0},
+		{"invalid JSON", http.StatusOK, `<html>maintenance</html>`, 0},
+		{"timeout", http.StatusOK, `{"success":true,"base":"USD","rates":{}}`, 500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, _ := newTestExchangerateHost(t, tt.status, tt.body, tt.delay)
+
+			rates, err := host.ForDate(context.Background(), "USD", day(2))
+			if err == nil {
+				t.Errorf("ForDate: got rates %+v, want an error", rates)
+			}
+			series, err := host.TimeSeries(context.Background(), "USD", "EUR", day(1), day(3))
+			if err == nil {
+				t.Errorf("TimeSeries: got series %+v, want an error", series)
+			}
+		})
+	}
+}
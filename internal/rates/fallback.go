@@ -0,0 +1,85 @@
+package rates
+
+import (
+	"context"
+	"strings"
+	"time"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/pkg/errors"
+)
+
+// Fallback asks providers in order and returns the first successful answer.
+type Fallback struct {
+	providers []RateProvider
+}
+
+func NewFallback(providers []RateProvider) *Fallback {
+	return &Fallback{
+		providers: providers,
+	}
+}
+
+func (f *Fallback) Name() string {
+	names := make([]string, 0, len(f.providers))
+	for _, provider := range f.providers {
+		names = append(names, provider.Name())
+	}
+	return "fallback(" + strings.Join(names, ",") + ")"
+}
+
+func (f *Fallback) Latest(
+	ctx context.Context,
+	base currency_helpers.CurrencyCode,
+) (*currency_helpers.CurrencyRates, error) {
+	var errs []string
+	for _, provider := range f.providers {
+		currencyRates, err := provider.Latest(ctx, base)
+		if err == nil {
+			return currencyRates, nil
+		}
+		errs = append(errs, errors.Wrap(err, provider.Name()).Error())
+	}
+
+	return nil, allProvidersFailed(errs)
+}
+
+func (f *Fallback) ForDate(
+	ctx context.Context,
+	base currency_helpers.CurrencyCode,
+	date time.Time,
+) (*currency_helpers.CurrencyRates, error) {
+	var errs []string
+	for _, provider := range f.providers {
+		currencyRates, err := provider.ForDate(ctx, base, date)
+		if err == nil {
+			return currencyRates, nil
+		}
+		errs = append(errs, errors.Wrap(err, provider.Name()).Error())
+	}
+
+	return nil, allProvidersFailed(errs)
+}
+
+func (f *Fallback) TimeSeries(
+	ctx context.Context,
+	base currency_helpers.CurrencyCode,
+	second currency_helpers.CurrencyCode,
+	start time.Time,
+	end time.Time,
+) (*currency_helpers.CurrencyTimelineRates, error) {
+	var errs []string
+	for _, provider := range f.providers {
+		timelineRates, err := provider.TimeSeries(ctx, base, second, start, end)
+		if err == nil {
+			return timelineRates, nil
+		}
+		errs = append(errs, errors.Wrap(err, provider.Name()).Error())
+	}
+
+	return nil, allProvidersFailed(errs)
+}
+
+func allProvidersFailed(errs []string) error {
+	return errors.Errorf("all rate providers failed: %s", strings.Join(errs, "; "))
+}
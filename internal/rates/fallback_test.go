@@ -0,0 +1,120 @@
+package rates
+
+import (
+	"context"
+	"testing"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/pkg/errors"
+)
+
+func TestFallback(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []*fakeProvider
+		// wantSource answered, wantCalls counts the calls of each provider in order
+		wantSource string
+		wantCalls  []int
+		wantErr    string
+	}{
+		{
+			name: "first answers",
+			providers: []*fakeProvider{
+				{name: "a", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.90"}},
+				{name: "b", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.91"}},
+			},
+			wantSource: "a",
+			wantCalls:  []int{1, 0},
+		},
+		{
+			name: "failover in order",
+			providers: []*fakeProvider{
+				{name: "a", err: errors.New("connection refused")},
+				{name: "b", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.91"}},
+				{name: "c", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.92"}},
+			},
+			wantSource: "b",
+			wantCalls:  []int{1, 1, 0},
+		},
+		{
+			name: "timed out",
+			providers: []*fakeProvider{
+				{name: "a", err: errTimeout},
+				{name: "b", err: errTimeout},
+				{name: "c", rates: map[currency_helpers.CurrencyCode]string{"EUR": "0.92"}},
+			},
+			wantSource: "c",
+			wantCalls:  []int{1, 1, 1},
+		},
+		{
+			name: "all failed",
+			providers: []*fakeProvider{
+				{name: "a", err: errors.New("connection refused")},
+				{name: "b", err: errTimeout},
+			},
+			wantCalls: []int{1, 1},
+			wantErr: "all rate providers failed: a: connection refused; " +
+				"b: Client.Timeout exceeded while awaiting headers",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := make([]RateProvider, 0, len(tt.providers))
+			for _, provider := range tt.providers {
+				providers = append(providers, provider)
+			}
+			fallback := NewFallback(providers)
+
+			check := func(method, source string, err error) {
+				t.Helper()
+				for i, provider := range tt.providers {
+					if provider.calls != tt.wantCalls[i] {
+						t.Errorf("%s: %s called %d times, want %d", method, provider.name, provider.calls, tt.wantCalls[i])
+					}
+					provider.calls = 0
+				}
+				if tt.wantErr != "" {
+					if err == nil || err.Error() != tt.wantErr {
+						t.Errorf("%s: got error %v, want %s", method, err, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("%s: %v", method, err)
+				}
+				if source != tt.wantSource {
+					t.Errorf("%s: got source %s, want %s", method, source, tt.wantSource)
+				}
+			}
+
+			rates, err := fallback.ForDate(context.Background(), "USD", day(2))
+			source := ""
+			if rates != nil {
+				source = rates.Source
+			}
+			check("ForDate", source, err)
+
+			rates, err = fallback.Latest(context.Background(), "USD")
+			source = ""
+			if rates != nil {
+				source = rates.Source
+			}
+			check("Latest", source, err)
+
+			// the fake answers every day in a single call of TimeSeries
+			series, err := fallback.TimeSeries(context.Background(), "USD", "EUR", day(2), day(2))
+			source = ""
+			if series != nil {
+				source = series.Source
+			}
+			check("TimeSeries", source, err)
+		})
+	}
+}
+
+func TestFallbackName(t *testing.T) {
+	fallback := NewFallback([]RateProvider{&fakeProvider{name: "cbr"}, &fakeProvider{name: "exchangerate_host"}})
+	if name := fallback.Name(); name != "fallback(cbr,exchangerate_host)" {
+		t.Errorf("got name %s", name)
+	}
+}
@@ -0,0 +1,73 @@
+package rates
+
+import (
+	"context"
+	"time"
+	"wallet-service/internal/config"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/pkg/errors"
+)
+
+// RateProvider is a source of currency exchange rates.
+type RateProvider interface {
+	// Name identifies the provider in the source of returned rates.
+	Name() string
+	// Latest returns the most recent rates published for the base currency.
+	Latest(ctx context.Context, base currency_helpers.CurrencyCode) (*currency_helpers.CurrencyRates, error)
+	// ForDate returns the rates for the base currency published for the given date.
+	ForDate(
+		ctx context.Context,
+		base currency_helpers.CurrencyCode,
+		date time.Time,
+	) (*currency_helpers.CurrencyRates, error)
+	// TimeSeries returns daily rates of the second currency against the base one for the period.
+	TimeSeries(
+		ctx context.Context,
+		base currency_helpers.CurrencyCode,
+		second currency_helpers.CurrencyCode,
+		start time.Time,
+		end time.Time,
+	) (*currency_helpers.CurrencyTimelineRates, error)
+}
+
+const (
+	ExchangerateHostProvider string = "exchangerate_host"
+	CBRProvider              string = "cbr"
+)
+
+func InitRateProvider(cfg *config.Config) (RateProvider, error) {
+	if len(cfg.RateProviders) == 0 {
+		return nil, errors.New("no rate providers configured")
+	}
+
+	providers := make([]RateProvider, 0, len(cfg.RateProviders))
+	for _, name := range cfg.RateProviders {
+		provider, err := newRateProvider(name, cfg)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+
+	if cfg.RateConsensus {
+		return NewConsensus(providers, cfg.RateConsensusMinSources, cfg.RateConsensusMaxDeviation), nil
+	}
+
+	if len(providers) == 1 {
+		return providers[0], nil
+	}
+
+	return NewFallback(providers), nil
+}
+
+func newRateProvider(name string, cfg *config.Config) (RateProvider, error) {
+	switch name {
+	case ExchangerateHostProvider:
+		return NewExchangerateHost(cfg.ExchangerAPIURL, cfg.ExchangerAPITimeout), nil
+	case CBRProvider:
+		return NewCBR(cfg.CBRXMLURL, cfg.ExchangerAPITimeout), nil
+	default:
+		return nil, errors.Errorf("unknown rate provider '%s'", name)
+	}
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"wallet-service/internal/apikey"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *HttpService) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	dbCtx, cancel := context.WithTimeout(r.Context(), s.cfg.DBTimeout)
+	defer cancel()
+	keys, err := s.apiKeys.List(dbCtx)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, keys)
+}
+
+// IssueAPIKey creates a partner key, the key itself is returned only in this response.
+func (s *HttpService) IssueAPIKey(w http.ResponseWriter, r *http.Request) {
+	req := apikey.Issue{}
+	defer r.Body.Close()
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, r, invalidBody(err))
+		return
+	}
+
+	dbCtx, cancel := context.WithTimeout(r.Context(), s.cfg.DBTimeout)
+	defer cancel()
+	issued, err := s.apiKeys.Issue(dbCtx, req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, http.StatusCreated, issued)
+}
+
+func (s *HttpService) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, badRequest("invalid api key id"))
+		return
+	}
+
+	dbCtx, cancel := context.WithTimeout(r.Context(), s.cfg.DBTimeout)
+	defer cancel()
+	key, err := s.apiKeys.Revoke(dbCtx, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, key)
+}
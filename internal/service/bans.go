@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+	"wallet-service/internal/apierror"
+	"wallet-service/internal/auth"
+	"wallet-service/internal/catalogue"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/pkg/errors"
+)
+
+// ChangeCurrencyBanStatusBulk validates every change first and applies them all in one transaction.
+// The report lists the result of each change, if any change is invalid nothing is applied
+// and the report with the errors of the invalid ones is returned in the error details.
+func (s *HttpService) ChangeCurrencyBanStatusBulk(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req []catalogue.BanChange
+	defer r.Body.Close()
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, r, invalidBody(err))
+		return
+	}
+	if len(req) == 0 {
+		writeError(w, r, badRequest("no changes"))
+		return
+	}
+
+	report := make([]catalogue.BanChangeResult, 0, len(req))
+	valid := true
+	for i := range req {
+		req[i].Author = banAuthor(ctx)
+	}
+	for _, change := range req {
+		result := catalogue.BanChangeResult{
+			Currency: change.Currency,
+			Banned:   change.Banned,
+		}
+		err = s.catalogue.ValidateBanChange(change)
+		if err != nil {
+			result.Error = err.Error()
+			valid = false
+		}
+		report = append(report, result)
+	}
+
+	if !valid {
+		writeError(w, r, apierror.New(
+			http.StatusBadRequest, apierror.CodeInvalidBan, "invalid ban changes, nothing was applied",
+		).WithDetails(report))
+		return
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, s.cfg.DBTimeout)
+	defer cancel()
+	report, err = s.catalogue.ChangeBans(dbCtx, req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	s.cleanAvailableCurrencies(ctx)
+
+	writeJSON(w, r, http.StatusOK, report)
+}
+
+// GetCurrencyBanHistory returns ban events of the currency, newest first.
+func (s *HttpService) GetCurrencyBanHistory(w http.ResponseWriter, r *http.Request) {
+	code := currencyCodeParam(r)
+	if _, ok := s.catalogue.Get(code); !ok {
+		writeError(w, r, errors.Wrapf(catalogue.ErrCurrencyNotFound, "'%s'", code))
+		return
+	}
+
+	filter, err := parseBanEventFilter(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	filter.Currency = code
+
+	s.writeBanEvents(w, r, filter)
+}
+
+// GetBanEvents returns the feed of ban events of all currencies, newest first.
+func (s *HttpService) GetBanEvents(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseBanEventFilter(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if currency := r.URL.Query().Get("currency"); currency != "" {
+		filter.Currency = currency_helpers.CurrencyCode(currency)
+	}
+
+	s.writeBanEvents(w, r, filter)
+}
+
+func (s *HttpService) writeBanEvents(w http.ResponseWriter, r *http.Request, filter catalogue.BanEventFilter) {
+	dbCtx, cancel := context.WithTimeout(r.Context(), s.cfg.DBTimeout)
+	defer cancel()
+	events, err := s.catalogue.BanEvents(dbCtx, filter)
+	if err != nil {
+		writeError(w, r, errors.Wrap(err, "error in get ban events"))
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, events)
+}
+
+// parseBanEventFilter reads action, author, from, to (RFC 3339) and limit query params.
+func parseBanEventFilter(r *http.Request) (catalogue.BanEventFilter, error) {
+	query := r.URL.Query()
+	filter := catalogue.BanEventFilter{
+		Action: catalogue.BanAction(query.Get("action")),
+		Author: query.Get("author"),
+	}
+
+	switch filter.Action {
+	case "", catalogue.BanActionBan, catalogue.BanActionLift:
+	default:
+		return filter, badRequest("invalid action")
+	}
+
+	var err error
+	if from := query.Get("from"); from != "" {
+		filter.From, err = time.Parse(time.RFC3339, from)
+		if err != nil {
+			return filter, badRequest("invalid from time")
+		}
+	}
+	if to := query.Get("to"); to != "" {
+		filter.To, err = time.Parse(time.RFC3339, to)
+		if err != nil {
+			return filter, badRequest("invalid to time")
+		}
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit <= 0 {
+			return filter, badRequest("invalid limit")
+		}
+	}
+
+	return filter, nil
+}
+
+// banAuthor is the subject of the authenticated admin changing bans.
+func banAuthor(ctx context.Context) string {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return principal.Subject
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"wallet-service/internal/catalogue"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+)
+
+func (s *HttpService) GetCurrency(w http.ResponseWriter, r *http.Request) {
+	code := currencyCodeParam(r)
+	currency, ok := s.catalogue.Get(code)
+	if !ok {
+		writeError(w, r, errors.Wrapf(catalogue.ErrCurrencyNotFound, "'%s'", code))
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, currency)
+}
+
+func (s *HttpService) AddCurrency(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := currency_helpers.CurrencyInfo{}
+	defer r.Body.Close()
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, r, invalidBody(err))
+		return
+	}
+	req.Code = currency_helpers.CurrencyCode(strings.ToUpper(req.Code.String()))
+
+	dbCtx, cancel := context.WithTimeout(ctx, s.cfg.DBTimeout)
+	defer cancel()
+	currency, err := s.catalogue.Add(dbCtx, req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	s.cleanAvailableCurrencies(ctx)
+
+	writeJSON(w, r, http.StatusCreated, currency)
+}
+
+func (s *HttpService) DescribeCurrency(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := catalogue.Description{}
+	defer r.Body.Close()
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, r, invalidBody(err))
+		return
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, s.cfg.DBTimeout)
+	defer cancel()
+	currency, err := s.catalogue.Describe(dbCtx, currencyCodeParam(r), req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	s.cleanAvailableCurrencies(ctx)
+
+	writeJSON(w, r, http.StatusOK, currency)
+}
+
+func (s *HttpService) EnableCurrency(w http.ResponseWriter, r *http.Request) {
+	s.setCurrencyEnabled(w, r, true)
+}
+
+// DisableCurrency stops the currency from being used in new operations,
+// existing accounts and balances in it are kept.
+func (s *HttpService) DisableCurrency(w http.ResponseWriter, r *http.Request) {
+	s.setCurrencyEnabled(w, r, false)
+}
+
+func (s *HttpService) setCurrencyEnabled(w http.ResponseWriter, r *http.Request, enabled bool) {
+	ctx := r.Context()
+
+	dbCtx, cancel := context.WithTimeout(ctx, s.cfg.DBTimeout)
+	defer cancel()
+	currency, err := s.catalogue.SetEnabled(dbCtx, currencyCodeParam(r), enabled)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	s.cleanAvailableCurrencies(ctx)
+
+	writeJSON(w, r, http.StatusOK, currency)
+}
+
+func (s *HttpService) cleanAvailableCurrencies(ctx context.Context) {
+	cacheCtx, cancel := context.WithTimeout(ctx, s.cfg.CacheTimeout)
+	defer cancel()
+	err := s.redisCache.CleanCacheForAvailableCurrencies(cacheCtx)
+	if err != nil {
+		log.Printf("error in clean available currencies: %s", err.Error())
+	}
+}
+
+func currencyCodeParam(r *http.Request) currency_helpers.CurrencyCode {
+	return currency_helpers.CurrencyCode(strings.ToUpper(chi.URLParam(r, "code")))
+}
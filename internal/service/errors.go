@@ -0,0 +1,108 @@
+package service
+
+import (
+	"log"
+	"net/http"
+	"wallet-service/internal/apierror"
+	"wallet-service/internal/apikey"
+	"wallet-service/internal/catalogue"
+	"wallet-service/internal/currency_helpers"
+	"wallet-service/internal/jobs"
+	"wallet-service/internal/ledger"
+	"wallet-service/internal/openapi"
+	"wallet-service/internal/trading"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/pkg/errors"
+)
+
+var (
+	errCacheFailure        = errors.New("cache is unavailable")
+	errProviderUnavailable = errors.New("rate provider is unavailable")
+	errRateNotFound        = errors.New("rate not found")
+	errPredictionsDisabled = errors.New("predictions are disabled")
+	errPredictionFailed    = errors.New("prediction failed")
+)
+
+type errorMapping struct {
+	status int
+	code   apierror.Code
+}
+
+// domainErrors maps errors of the domain packages to the response status and code.
+// Messages of client errors are written as is, server errors are reported by their cause only.
+var domainErrors = map[error]errorMapping{
+	catalogue.ErrCurrencyNotFound:        {http.StatusNotFound, apierror.CodeCurrencyNotFound},
+	catalogue.ErrCurrencyExists:          {http.StatusConflict, apierror.CodeCurrencyExists},
+	catalogue.ErrInvalidCurrency:         {http.StatusBadRequest, apierror.CodeInvalidCurrency},
+	catalogue.ErrInvalidBan:              {http.StatusBadRequest, apierror.CodeInvalidBan},
+	trading.ErrPairBlocked:               {http.StatusUnprocessableEntity, apierror.CodePairBlocked},
+	trading.ErrAmountExceeded:            {http.StatusUnprocessableEntity, apierror.CodeAmountExceeded},
+	trading.ErrInvalidRule:               {http.StatusBadRequest, apierror.CodeInvalidTradingRule},
+	trading.ErrRuleNotFound:              {http.StatusNotFound, apierror.CodeTradingRuleNotFound},
+	ledger.ErrAccountNotFound:            {http.StatusUnprocessableEntity, apierror.CodeAccountNotFound},
+	ledger.ErrInsufficientFunds:          {http.StatusUnprocessableEntity, apierror.CodeInsufficientFunds},
+	ledger.ErrInvalidAmount:              {http.StatusBadRequest, apierror.CodeInvalidAmount},
+	ledger.ErrUnbalancedEntry:            {http.StatusBadRequest, apierror.CodeInvalidAmount},
+	currency_helpers.ErrInvalidAmount:    {http.StatusBadRequest, apierror.CodeInvalidAmount},
+	currency_helpers.ErrCurrencyMismatch: {http.StatusBadRequest, apierror.CodeInvalidAmount},
+	apikey.ErrInvalidKey:                 {http.StatusBadRequest, apierror.CodeInvalidAPIKey},
+	apikey.ErrKeyNotFound:                {http.StatusNotFound, apierror.CodeAPIKeyNotFound},
+	jobs.ErrJobNotFound:                  {http.StatusNotFound, apierror.CodePredictionJobNotFound},
+	openapi.ErrBodyTooLarge:              {http.StatusRequestEntityTooLarge, apierror.CodeBodyTooLarge},
+	errAmountTooSmall:                    {http.StatusBadRequest, apierror.CodeInvalidAmount},
+	errRateNotFound:                      {http.StatusUnprocessableEntity, apierror.CodeRateNotFound},
+	errCacheFailure:                      {http.StatusServiceUnavailable, apierror.CodeCacheFailure},
+	errProviderUnavailable:               {http.StatusServiceUnavailable, apierror.CodeProviderUnavailable},
+	errPredictionsDisabled:               {http.StatusServiceUnavailable, apierror.CodePredictionsDisabled},
+}
+
+// writeError renders err as a JSON error response. Errors built with apierror are written as is
+// and known domain errors get their status and code. Anything else is logged with the request id
+// and reported as an internal error, so database and provider internals do not leak to clients.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var apiErr *apierror.Error
+	if errors.As(err, &apiErr) {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+
+	cause := errors.Cause(err)
+	mapping, ok := domainErrors[cause]
+	if !ok {
+		mapping = errorMapping{http.StatusInternalServerError, apierror.CodeInternal}
+		cause = errors.New("internal error")
+	}
+
+	message := err.Error()
+	if mapping.status >= http.StatusInternalServerError {
+		log.Printf("[%s] %s", middleware.GetReqID(r.Context()), err.Error())
+		message = cause.Error()
+	}
+	apierror.Write(w, r, apierror.New(mapping.status, mapping.code, message))
+}
+
+// badRequest reports a malformed request or an invalid parameter.
+func badRequest(message string) error {
+	return apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, message)
+}
+
+// invalidCurrency reports a currency missing from the catalogue or disabled.
+func invalidCurrency(message string) error {
+	return apierror.New(http.StatusBadRequest, apierror.CodeInvalidCurrency, message)
+}
+
+// cacheError marks a failed cache call, so it is reported as a cache failure.
+func cacheError(err error, message string) error {
+	return errors.Wrapf(errCacheFailure, "%s: %s", message, err.Error())
+}
+
+// providerError marks a failed rate provider call, so it is reported as the provider being unavailable.
+func providerError(err error, message string) error {
+	return errors.Wrapf(errProviderUnavailable, "%s: %s", message, err.Error())
+}
+
+// invalidBody reports a request body that cannot be decoded.
+func invalidBody(err error) error {
+	return badRequest("invalid request body: " + err.Error())
+}
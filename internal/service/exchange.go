@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"wallet-service/internal/apierror"
+	"wallet-service/internal/auth"
+	"wallet-service/internal/currency_helpers"
+	"wallet-service/internal/ledger"
+	"wallet-service/internal/wallet"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+)
+
+var errAmountTooSmall = errors.New("amount is too small to exchange")
+
+func (s *HttpService) ExchangeInWallet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	walletID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, badRequest("invalid wallet id"))
+		return
+	}
+
+	req := struct {
+		From    currency_helpers.CurrencyCode `json:"from"`
+		To      currency_helpers.CurrencyCode `json:"to"`
+		Amount  currency_helpers.Decimal      `json:"amount"`
+		QuoteID string                        `json:"quoteId"`
+	}{}
+	defer r.Body.Close()
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, r, invalidBody(err))
+		return
+	}
+
+	var quote *currency_helpers.Quote
+	if req.QuoteID != "" {
+		cacheCtx, cancel := context.WithTimeout(ctx, s.cfg.CacheTimeout)
+		defer cancel()
+		quote, err = s.redisCache.GetQuote(cacheCtx, req.QuoteID)
+		if err != nil {
+			writeError(w, r, cacheError(err, "error in get quote"))
+			return
+		}
+		// quotes of other users are reported as missing, like their wallets
+		principal, _ := auth.FromContext(ctx)
+		if quote == nil || quote.Owner != principal.Subject || time.Now().After(quote.ExpiresAt) {
+			writeError(w, r, apierror.New(http.StatusGone, apierror.CodeQuoteNotFound, "quote not found or expired"))
+			return
+		}
+
+		if !req.From.IsSet() {
+			req.From = quote.Base
+		}
+		if !req.To.IsSet() {
+			req.To = quote.Second
+		}
+		if req.Amount.IsZero() {
+			req.Amount = quote.Amount
+		}
+		if req.From != quote.Base || req.To != quote.Second || !req.Amount.Equal(quote.Amount) {
+			writeError(w, r, apierror.New(
+				http.StatusBadRequest, apierror.CodeQuoteMismatch, "request does not match the quote",
+			))
+			return
+		}
+	}
+
+	if !s.catalogue.Supported(req.From) {
+		writeError(w, r, invalidCurrency("invalid from currency"))
+		return
+	}
+	if !s.catalogue.Supported(req.To) {
+		writeError(w, r, invalidCurrency("invalid to currency"))
+		return
+	}
+	if req.From == req.To {
+		writeError(w, r, badRequest("cannot exchange currency to itself"))
+		return
+	}
+
+	amount := s.catalogue.Money(req.Amount, req.From)
+	err = amount.Validate()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	for _, currency := range []currency_helpers.CurrencyCode{req.From, req.To} {
+		if s.catalogue.IsBanned(currency) {
+			writeError(w, r, apierror.Errorf(
+				http.StatusUnprocessableEntity, apierror.CodeCurrencyBanned, "currency '%s' is banned", currency,
+			))
+			return
+		}
+	}
+
+	err = s.tradingRules.CheckTrade(req.From, req.To, req.Amount)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	_, err = s.getWallet(ctx, walletID)
+	if err != nil {
+		writeError(w, r, walletError(err, walletID))
+		return
+	}
+
+	var currencyRate *currency_helpers.CurrencyRate
+	if quote != nil {
+		cacheCtx, cancel := context.WithTimeout(ctx, s.cfg.CacheTimeout)
+		defer cancel()
+		used, err := s.redisCache.UseQuote(cacheCtx, quote)
+		if err != nil {
+			writeError(w, r, cacheError(err, "error in use quote"))
+			return
+		}
+		if !used {
+			writeError(w, r, apierror.New(http.StatusConflict, apierror.CodeQuoteUsed, "quote is already used or expired"))
+			return
+		}
+		currencyRate = quote.ToResultRate()
+	} else {
+		currencyRate, err = s.currentRate(ctx, req.From, req.To)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+
+	result, err := s.exchange(ctx, walletID, amount, currencyRate)
+	if err != nil {
+		if quote != nil {
+			// the trade did not happen, so the quote can be used again
+			cacheCtx, cancel := context.WithTimeout(ctx, s.cfg.CacheTimeout)
+			defer cancel()
+			releaseErr := s.redisCache.ReleaseQuote(cacheCtx, quote.ID)
+			if releaseErr != nil {
+				log.Printf("error in release quote: %s", releaseErr.Error())
+			}
+		}
+
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusCreated, result)
+}
+
+// exchange converts amount of the rate base currency into the second one,
+// posting the ledger entry and recording the trade in one transaction.
+func (s *HttpService) exchange(
+	ctx context.Context,
+	walletID int64,
+	amount currency_helpers.Money,
+	currencyRate *currency_helpers.CurrencyRate,
+) (*wallet.Exchange, error) {
+	rate := currencyRate.Rate
+	if !rate.IsPositive() {
+		return nil, errors.Errorf("invalid rate %s", rate)
+	}
+	spread := s.cfg.ExchangeSpread
+
+	// effective rate = rate * (100 - spread) / 100
+	effectiveRate := currency_helpers.DivideRate(
+		rate.Mul(currency_helpers.Hundred.Sub(spread)),
+		currency_helpers.Hundred,
+	)
+
+	// both amounts are rounded down in favour of the exchange
+	minorUnits := s.catalogue.MinorUnits(currencyRate.Second)
+	marketAmount := amount.Convert(rate, currencyRate.Second, minorUnits, currency_helpers.RoundDown)
+	toAmount := amount.Convert(effectiveRate, currencyRate.Second, minorUnits, currency_helpers.RoundDown)
+	if !toAmount.IsPositive() {
+		return nil, errAmountTooSmall
+	}
+	fee, err := marketAmount.Sub(toAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &wallet.Exchange{
+		WalletID:      walletID,
+		FromCurrency:  currencyRate.Base,
+		ToCurrency:    currencyRate.Second,
+		FromAmount:    amount.Amount,
+		ToAmount:      toAmount.Amount,
+		Rate:          rate,
+		EffectiveRate: effectiveRate,
+		Spread:        spread,
+		Fee:           fee.Amount,
+		RateDate:      currencyRate.Date.Time,
+		RateSource:    currencyRate.Source,
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, s.cfg.DBTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(dbCtx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	fromID, err := ledger.AccountID(dbCtx, tx, walletID, result.FromCurrency)
+	if err != nil {
+		return nil, err
+	}
+	toID, err := ledger.AccountID(dbCtx, tx, walletID, result.ToCurrency)
+	if err != nil {
+		return nil, err
+	}
+	exchangeFromID, err := ledger.SystemAccountID(dbCtx, tx, ledger.ExchangeWallet, result.FromCurrency)
+	if err != nil {
+		return nil, err
+	}
+	exchangeToID, err := ledger.SystemAccountID(dbCtx, tx, ledger.ExchangeWallet, result.ToCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &ledger.Entry{
+		Kind:        ledger.Exchange,
+		Description: fmt.Sprintf("exchange %s to %s", amount, toAmount),
+		Postings: []ledger.Posting{
+			{AccountID: fromID, Currency: result.FromCurrency, Amount: result.FromAmount.Neg()},
+			{AccountID: exchangeFromID, Currency: result.FromCurrency, Amount: result.FromAmount},
+			{AccountID: exchangeToID, Currency: result.ToCurrency, Amount: result.ToAmount.Neg()},
+			{AccountID: toID, Currency: result.ToCurrency, Amount: result.ToAmount},
+		},
+	}
+	err = ledger.Post(dbCtx, tx, s.catalogue, entry)
+	if err != nil {
+		return nil, err
+	}
+	result.EntryID = entry.ID
+
+	query := `
+		insert into exchanges (
+			wallet_id, entry_id, from_currency, to_currency, from_amount, to_amount,
+			rate, effective_rate, spread, fee, rate_date, rate_source
+		)
+		values (
+			:wallet_id, :entry_id, :from_currency, :to_currency, :from_amount, :to_amount,
+			:rate, :effective_rate, :spread, :fee, :rate_date, :rate_source
+		)
+		returning id, created_at;
+	`
+	query, params, err := tx.BindNamed(query, result)
+	if err != nil {
+		return nil, errors.Wrap(err, "prepare exchange query")
+	}
+	err = tx.QueryRowxContext(dbCtx, query, params...).Scan(&result.ID, &result.CreatedAt)
+	if err != nil {
+		return nil, errors.Wrap(err, "insert exchange")
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, errors.Wrap(err, "commit transaction")
+	}
+
+	return result, nil
+}
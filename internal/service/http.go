@@ -3,17 +3,43 @@ package service
 import (
 	"github.com/jmoiron/sqlx"
 	"net/http"
+	"wallet-service/internal/accuracy"
+	"wallet-service/internal/apikey"
+	"wallet-service/internal/auth"
 	"wallet-service/internal/cache"
+	"wallet-service/internal/catalogue"
 	"wallet-service/internal/config"
+	"wallet-service/internal/cors"
+	"wallet-service/internal/jobs"
+	"wallet-service/internal/predictor"
+	"wallet-service/internal/rates"
+	"wallet-service/internal/trading"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func InitRouter(db *sqlx.DB, redisCache cache.Cache, cfg *config.Config) http.Handler {
-	s := NewService(db, redisCache, cfg)
+func InitRouter(
+	db *sqlx.DB,
+	redisCache cache.Cache,
+	rateProvider rates.RateProvider,
+	currencyCatalogue *catalogue.Catalogue,
+	tradingRules *trading.Engine,
+	corsPolicy *cors.Policy,
+	verifier *auth.Verifier,
+	apiKeys *apikey.Store,
+	predictors *predictor.Models,
+	predictionAccuracy *accuracy.Tracker,
+	predictionJobs *jobs.Queue,
+	cfg *config.Config,
+) http.Handler {
+	spec := newAPISpec()
+	s := NewService(
+		db, redisCache, rateProvider, currencyCatalogue, tradingRules, apiKeys,
+		predictors, predictionAccuracy, predictionJobs, spec, cfg,
+	)
 
 	r := chi.NewRouter()
-	initMiddlewares(r, s)
+	initMiddlewares(r, s, corsPolicy, verifier, apiKeys, redisCache, spec, cfg)
 	initRoutes(r, s)
 
 	return r
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"wallet-service/internal/apierror"
+	"wallet-service/internal/currency_helpers"
+	"wallet-service/internal/ledger"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+const defaultEntriesLimit = 100
+
+type moneyRequest struct {
+	Currency    currency_helpers.CurrencyCode `json:"currency"`
+	Amount      currency_helpers.Decimal      `json:"amount"`
+	Description string                        `json:"description"`
+}
+
+func (s *HttpService) DepositToWallet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	walletID, req, ok := s.decodeMoneyRequest(w, r)
+	if !ok {
+		return
+	}
+
+	entry, err := s.postEntry(ctx, func(ctx context.Context, tx *sqlx.Tx) (*ledger.Entry, error) {
+		accountID, err := ledger.AccountID(ctx, tx, walletID, req.Currency)
+		if err != nil {
+			return nil, err
+		}
+		externalID, err := ledger.SystemAccountID(ctx, tx, ledger.ExternalWallet, req.Currency)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ledger.Entry{
+			Kind:        ledger.Deposit,
+			Description: req.Description,
+			Postings: []ledger.Posting{
+				{AccountID: accountID, Currency: req.Currency, Amount: req.Amount},
+				{AccountID: externalID, Currency: req.Currency, Amount: req.Amount.Neg()},
+			},
+		}, nil
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusCreated, entry)
+}
+
+func (s *HttpService) WithdrawFromWallet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	walletID, req, ok := s.decodeMoneyRequest(w, r)
+	if !ok {
+		return
+	}
+
+	entry, err := s.postEntry(ctx, func(ctx context.Context, tx *sqlx.Tx) (*ledger.Entry, error) {
+		accountID, err := ledger.AccountID(ctx, tx, walletID, req.Currency)
+		if err != nil {
+			return nil, err
+		}
+		externalID, err := ledger.SystemAccountID(ctx, tx, ledger.ExternalWallet, req.Currency)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ledger.Entry{
+			Kind:        ledger.Withdrawal,
+			Description: req.Description,
+			Postings: []ledger.Posting{
+				{AccountID: accountID, Currency: req.Currency, Amount: req.Amount.Neg()},
+				{AccountID: externalID, Currency: req.Currency, Amount: req.Amount},
+			},
+		}, nil
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusCreated, entry)
+}
+
+func (s *HttpService) TransferFromWallet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	walletID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, badRequest("invalid wallet id"))
+		return
+	}
+
+	req := struct {
+		moneyRequest
+		ToWalletID int64 `json:"toWalletId"`
+	}{}
+	defer r.Body.Close()
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, r, invalidBody(err))
+		return
+	}
+
+	if req.ToWalletID == walletID {
+		writeError(w, r, badRequest("cannot transfer to the same wallet"))
+		return
+	}
+
+	if !s.validateMoneyRequest(w, r, &req.moneyRequest) {
+		return
+	}
+
+	_, err = s.getWallet(ctx, walletID)
+	if err != nil {
+		writeError(w, r, walletError(err, walletID))
+		return
+	}
+	// money can be sent to a wallet of any user
+	_, err = s.findWallet(ctx, req.ToWalletID)
+	if err != nil {
+		writeError(w, r, walletError(err, req.ToWalletID))
+		return
+	}
+
+	entry, err := s.postEntry(ctx, func(ctx context.Context, tx *sqlx.Tx) (*ledger.Entry, error) {
+		fromID, err := ledger.AccountID(ctx, tx, walletID, req.Currency)
+		if err != nil {
+			return nil, err
+		}
+		toID, err := ledger.AccountID(ctx, tx, req.ToWalletID, req.Currency)
+		if err != nil {
+			return nil, errors.Wrap(err, "destination")
+		}
+
+		return &ledger.Entry{
+			Kind:        ledger.Transfer,
+			Description: req.Description,
+			Postings: []ledger.Posting{
+				{AccountID: fromID, Currency: req.Currency, Amount: req.Amount.Neg()},
+				{AccountID: toID, Currency: req.Currency, Amount: req.Amount},
+			},
+		}, nil
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusCreated, entry)
+}
+
+func (s *HttpService) GetWalletEntries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	walletID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, badRequest("invalid wallet id"))
+		return
+	}
+
+	limit := defaultEntriesLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			writeError(w, r, badRequest("invalid limit"))
+			return
+		}
+	}
+
+	_, err = s.getWallet(ctx, walletID)
+	if err != nil {
+		writeError(w, r, walletError(err, walletID))
+		return
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, s.cfg.DBTimeout)
+	defer cancel()
+	entries, err := ledger.WalletEntries(dbCtx, s.db, walletID, limit)
+	if err != nil {
+		writeError(w, r, errors.Wrap(err, "error in get wallet entries"))
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, entries)
+}
+
+func (s *HttpService) CheckLedger(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	dbCtx, cancel := context.WithTimeout(ctx, s.cfg.DBTimeout)
+	defer cancel()
+	report, err := ledger.Check(dbCtx, s.db)
+	if err != nil {
+		writeError(w, r, errors.Wrap(err, "error in check ledger"))
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, report)
+}
+
+func (s *HttpService) decodeMoneyRequest(w http.ResponseWriter, r *http.Request) (int64, *moneyRequest, bool) {
+	walletID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, badRequest("invalid wallet id"))
+		return 0, nil, false
+	}
+
+	req := &moneyRequest{}
+	defer r.Body.Close()
+	err = json.NewDecoder(r.Body).Decode(req)
+	if err != nil {
+		writeError(w, r, invalidBody(err))
+		return 0, nil, false
+	}
+
+	if !s.validateMoneyRequest(w, r, req) {
+		return 0, nil, false
+	}
+
+	_, err = s.getWallet(r.Context(), walletID)
+	if err != nil {
+		writeError(w, r, walletError(err, walletID))
+		return 0, nil, false
+	}
+
+	return walletID, req, true
+}
+
+func (s *HttpService) validateMoneyRequest(w http.ResponseWriter, r *http.Request, req *moneyRequest) bool {
+	if !s.catalogue.Supported(req.Currency) {
+		writeError(w, r, invalidCurrency("invalid currency"))
+		return false
+	}
+
+	err := s.catalogue.Money(req.Amount, req.Currency).Validate()
+	if err != nil {
+		writeError(w, r, err)
+		return false
+	}
+
+	if s.catalogue.IsBanned(req.Currency) {
+		writeError(w, r, apierror.Errorf(
+			http.StatusUnprocessableEntity, apierror.CodeCurrencyBanned, "currency '%s' is banned", req.Currency,
+		))
+		return false
+	}
+
+	return true
+}
+
+// postEntry builds and posts a journal entry in a single transaction.
+func (s *HttpService) postEntry(
+	ctx context.Context,
+	build func(ctx context.Context, tx *sqlx.Tx) (*ledger.Entry, error),
+) (*ledger.Entry, error) {
+	dbCtx, cancel := context.WithTimeout(ctx, s.cfg.DBTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(dbCtx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	entry, err := build(dbCtx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ledger.Post(dbCtx, tx, s.catalogue, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, errors.Wrap(err, "commit transaction")
+	}
+
+	return entry, nil
+}
@@ -1,22 +1,188 @@
 package service
 
 import (
+	"context"
+	"log"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
+	"wallet-service/internal/apierror"
+	"wallet-service/internal/apikey"
+	"wallet-service/internal/auth"
+	"wallet-service/internal/cache"
+	"wallet-service/internal/config"
+	"wallet-service/internal/cors"
+	"wallet-service/internal/openapi"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/pkg/errors"
 )
 
-func initMiddlewares(r chi.Router, s Service) {
+func initMiddlewares(
+	r chi.Router,
+	s Service,
+	corsPolicy *cors.Policy,
+	verifier *auth.Verifier,
+	apiKeys *apikey.Store,
+	redisCache cache.Cache,
+	spec *openapi.Document,
+	cfg *config.Config,
+) {
 	r.Use(
+		middleware.RequestID,
+		exposeRequestID,
 		middleware.Logger,
-		func(handler http.Handler) http.Handler {
-			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				w.Header().Set("Access-Control-Allow-Origin", "*")
-				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, PUT")
-				w.Header().Set("Access-Control-Allow-Headers", "*")
-				handler.ServeHTTP(w, r)
-			})
-		},
+		corsPolicy.Handler,
+		negotiate,
+		authenticate(verifier),
+		limitRequests(apiKeys, redisCache, cfg),
+		validateRequests(spec, r, cfg.MaxRequestBodySize),
 	)
 }
+
+// exposeRequestID returns the request id, which error responses also carry, in the X-Request-Id header.
+func exposeRequestID(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(middleware.RequestIDHeader, middleware.GetReqID(r.Context()))
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// authenticate attaches the principal of a bearer token to the request context.
+// Requests without a token pass as anonymous, routes restrict them with requireRole.
+func authenticate(verifier *auth.Verifier) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if header == "" {
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			token, ok := auth.BearerToken(header)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, r, apierror.New(
+					http.StatusUnauthorized, apierror.CodeUnauthorized, "authorization header must be a bearer token",
+				))
+				return
+			}
+			principal, err := verifier.Verify(token)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				writeError(w, r, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, err.Error()))
+				return
+			}
+
+			handler.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// requireRole lets through principals having any of the roles.
+func requireRole(roles ...auth.Role) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := auth.FromContext(r.Context())
+			if !ok {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, r, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, "authentication required"))
+				return
+			}
+			if !principal.HasRole(roles...) {
+				writeError(w, r, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "insufficient role"))
+				return
+			}
+
+			handler.ServeHTTP(w, r)
+		})
+	}
+}
+
+// limitRequests enforces the rate limit and daily quota of partner api keys sent in the X-API-Key header.
+// Requests with neither a key nor a bearer token are limited per client address instead,
+// so the public rate routes cannot be used without limits by leaving the key out.
+// If the counters are unavailable the request is let through.
+func limitRequests(apiKeys *apikey.Store, redisCache cache.Cache, cfg *config.Config) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			now := time.Now()
+			var (
+				usage apikey.Usage
+				err   error
+			)
+
+			raw := r.Header.Get("X-API-Key")
+			if raw == "" {
+				if _, ok := auth.FromContext(r.Context()); ok || cfg.AnonymousRateLimit <= 0 {
+					handler.ServeHTTP(w, r)
+					return
+				}
+
+				address := clientAddress(r)
+				cacheCtx, cancel := context.WithTimeout(r.Context(), cfg.CacheTimeout)
+				usage, err = apikey.LimitAnonymous(cacheCtx, redisCache, address, cfg.AnonymousRateLimit, now)
+				cancel()
+				if err != nil && !errors.Is(err, apikey.ErrAnonymousRateLimited) {
+					log.Printf("error in count request from %s: %s", address, err.Error())
+					handler.ServeHTTP(w, r)
+					return
+				}
+			} else {
+				key, ok := apiKeys.Authenticate(raw)
+				if !ok {
+					writeError(w, r, apierror.New(http.StatusUnauthorized, apierror.CodeInvalidAPIKey, apikey.ErrInvalidKey.Error()))
+					return
+				}
+
+				cacheCtx, cancel := context.WithTimeout(r.Context(), cfg.CacheTimeout)
+				usage, err = apikey.Limit(cacheCtx, redisCache, key, now)
+				cancel()
+				if err != nil && !errors.Is(err, apikey.ErrRateLimited) && !errors.Is(err, apikey.ErrQuotaExceeded) {
+					log.Printf("error in count api key %d request: %s", key.ID, err.Error())
+					handler.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			writeRateLimitHeaders(w, usage)
+			if err != nil {
+				retryAfter := int(math.Ceil(usage.ResetAt.Sub(now).Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				code := apierror.CodeRateLimited
+				if errors.Is(err, apikey.ErrQuotaExceeded) {
+					code = apierror.CodeQuotaExceeded
+				}
+				writeError(w, r, apierror.New(http.StatusTooManyRequests, code, err.Error()).WithDetails(
+					map[string]int{"retryAfter": retryAfter},
+				))
+				return
+			}
+
+			handler.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientAddress is the host of the connection the request came from. Forwarded headers are ignored,
+// since any client could set them to spread its requests over made up addresses.
+func clientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func writeRateLimitHeaders(w http.ResponseWriter, usage apikey.Usage) {
+	remaining := usage.Remaining
+	if remaining < 0 {
+		remaining = 0
+	}
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(usage.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(usage.ResetAt.Unix(), 10))
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"wallet-service/internal/auth"
+	"wallet-service/internal/config"
+
+	"github.com/pkg/errors"
+)
+
+func TestLimitRequestsAnonymous(t *testing.T) {
+	counter := &fakeCache{}
+	handler := limitRequests(nil, counter, &config.Config{CacheTimeout: time.Second, AnonymousRateLimit: 2})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeNoContent(w)
+		}),
+	)
+	request := func(address string, principal *auth.Principal) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/currency/current-rate", nil)
+		r.RemoteAddr = address
+		if principal != nil {
+			r = r.WithContext(auth.WithPrincipal(r.Context(), principal))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	for i, remaining := range []int{1, 0} {
+		w := request("192.0.2.1:5000", nil)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("request %d: got status %d", i, w.Code)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != strconv.Itoa(remaining) {
+			t.Errorf("request %d: got remaining %s, want %d", i, got, remaining)
+		}
+	}
+
+	// the limit is per address, whatever the port
+	w := request("192.0.2.1:5001", nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if w.Header().Get("Retry-After") == "" || w.Header().Get("X-RateLimit-Limit") != "2" {
+		t.Errorf("got headers %v", w.Header())
+	}
+	if code := `"code":"rate_limited"`; !strings.Contains(w.Body.String(), code) {
+		t.Errorf("got body %s, want %s", w.Body.String(), code)
+	}
+
+	if w := request("192.0.2.2:5000", nil); w.Code != http.StatusNoContent {
+		t.Errorf("other address: got status %d", w.Code)
+	}
+	w = request("192.0.2.1:5000", &auth.Principal{Subject: "user", Roles: []auth.Role{auth.RoleUser}})
+	if w.Code != http.StatusNoContent || w.Header().Get("X-RateLimit-Limit") != "" {
+		t.Errorf("authenticated: got status %d and headers %v", w.Code, w.Header())
+	}
+}
+
+func TestLimitRequestsWithoutCounter(t *testing.T) {
+	// requests are let through while the counters are unavailable
+	counter := &fakeCache{err: errors.New("connection refused")}
+	handler := limitRequests(nil, counter, &config.Config{CacheTimeout: time.Second, AnonymousRateLimit: 1})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeNoContent(w)
+		}),
+	)
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/currency/current-rate", nil))
+		if w.Code != http.StatusNoContent {
+			t.Errorf("request %d: got status %d", i, w.Code)
+		}
+	}
+}
@@ -0,0 +1,792 @@
+package service
+
+import (
+	"net/http"
+	"wallet-service/internal/apierror"
+	"wallet-service/internal/openapi"
+	"wallet-service/internal/predictor"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+)
+
+func (s *HttpService) GetOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, http.StatusOK, s.spec)
+}
+
+// validateRequests rejects requests whose parameters or body do not match the operation of the route.
+// Bodies over maxBodySize bytes are rejected with 413, requests matching no route are passed on for the router to answer.
+func validateRequests(spec *openapi.Document, routes chi.Routes, maxBodySize int64) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+
+			rctx := chi.NewRouteContext()
+			if !routes.Match(rctx, r.Method, r.URL.Path) {
+				handler.ServeHTTP(w, r)
+				return
+			}
+			operation, ok := spec.Operation(r.Method, openapi.RoutePattern(rctx.RoutePattern()))
+			if !ok {
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			fieldErrors, err := spec.ValidateRequest(operation, r, rctx.URLParam)
+			if errors.Is(err, openapi.ErrBodyTooLarge) {
+				writeError(w, r, err)
+				return
+			}
+			if err != nil {
+				writeError(w, r, errors.Wrap(err, "error in validate request"))
+				return
+			}
+			if len(fieldErrors) > 0 {
+				writeError(w, r, apierror.New(
+					http.StatusBadRequest, apierror.CodeInvalidRequest, "request does not match the api specification",
+				).WithDetails(fieldErrors))
+				return
+			}
+
+			handler.ServeHTTP(w, r)
+		})
+	}
+}
+
+// newAPISpec describes every route registered in initRoutes. The operations are written by hand,
+// TestAPISpecMatchesRoutes fails when a route is missing here or an operation has no route,
+// and requests are validated against the parameters and bodies below.
+func newAPISpec() *openapi.Document {
+	bearer := []map[string][]string{{"bearerAuth": {}}}
+	banEventFilters := []openapi.Parameter{
+		queryParam("action", false, enumSchema("ban", "lift")),
+		queryParam("author", false, stringSchema()),
+		queryParam("from", false, formatSchema("date-time")),
+		queryParam("to", false, formatSchema("date-time")),
+		queryParam("limit", false, positiveIntegerSchema()),
+	}
+	walletID := pathParam("id", positiveIntegerSchema())
+
+	return &openapi.Document{
+		OpenAPI: "3.0.3",
+		Info: openapi.Info{
+			Title:   "wallet-service",
+			Version: "1.0.0",
+			Description: "Currency rates, multi-currency wallets and exchanges. " +
+				"Errors are returned as the Error schema with a stable code.",
+		},
+		Paths: map[string]*openapi.PathItem{
+			"/openapi.json": {
+				"get": {
+					OperationID: "getOpenAPI",
+					Summary:     "This document",
+					Tags:        []string{"meta"},
+					Responses:   responds("200", "OpenAPI document", objectSchema(nil)),
+				},
+			},
+
+			"/currency/available": {
+				"get": {
+					OperationID: "getAvailableCurrencies",
+					Summary:     "Enabled currencies with their ban status, banned ones first",
+					Tags:        []string{"currencies"},
+					Responses:   responds("200", "Available currencies", arraySchema(openapi.Ref("CurrencyWithBanStatus"))),
+				},
+			},
+			"/currency/current-rate": {
+				"get": {
+					OperationID: "getCurrentCurrencyRate",
+					Summary:     "Last known rate of the pair",
+					Tags:        []string{"rates"},
+					Parameters: []openapi.Parameter{
+						queryParam("base", true, openapi.Ref("CurrencyCode")),
+						queryParam("second", true, openapi.Ref("CurrencyCode")),
+					},
+					Responses: responds("200", "Rate", openapi.Ref("CurrencyRate")),
+				},
+			},
+			"/currency/time-series": {
+				"get": {
+					OperationID: "getTimelineCurrencyRate",
+					Summary:     "Daily rates of the pair for the period with predictions",
+					Tags:        []string{"rates"},
+					Parameters: []openapi.Parameter{
+						queryParam("base", true, openapi.Ref("CurrencyCode")),
+						queryParam("second", true, openapi.Ref("CurrencyCode")),
+						queryParam("start", true, formatSchema("date")),
+						queryParam("end", true, formatSchema("date")),
+						queryParam("model", false, enumSchema(predictor.ModelNames...)),
+					},
+					Responses: responds("200", "Rates", openapi.Ref("CurrencyTimelineRate")),
+				},
+			},
+			"/currency/predictions/jobs": {
+				"post": {
+					OperationID: "createPredictionJob",
+					Summary:     "Enqueue generating the forecast of the pair, or return its job queued, running or finished today",
+					Tags:        []string{"rates"},
+					Security:    bearer,
+					RequestBody: jsonBody(objectSchema(map[string]*openapi.Schema{
+						"base":   openapi.Ref("CurrencyCode"),
+						"second": openapi.Ref("CurrencyCode"),
+						"model":  enumSchema(predictor.ModelNames...),
+					}, "base", "second")),
+					Responses: responds("202", "Job", openapi.Ref("PredictionJob")),
+				},
+			},
+			"/currency/predictions/jobs/{id}": {
+				"get": {
+					OperationID: "getPredictionJob",
+					Summary:     "Status of the prediction job, with the forecast once it is done",
+					Tags:        []string{"rates"},
+					Parameters:  []openapi.Parameter{pathParam("id", positiveIntegerSchema())},
+					Responses:   responds("200", "Job", openapi.Ref("PredictionJob")),
+				},
+			},
+			"/currency/predictions/accuracy": {
+				"get": {
+					OperationID: "getPredictionAccuracy",
+					Summary:     "Errors of past predictions against realized rates by pair, model and horizon",
+					Tags:        []string{"rates"},
+					Security:    bearer,
+					Parameters: []openapi.Parameter{
+						queryParam("base", false, openapi.Ref("CurrencyCode")),
+						queryParam("second", false, openapi.Ref("CurrencyCode")),
+						queryParam("model", false, enumSchema(predictor.ModelNames...)),
+					},
+					Responses: responds("200", "Accuracy", arraySchema(openapi.Ref("PredictionAccuracy"))),
+				},
+			},
+			"/currency/quote": {
+				"post": {
+					OperationID: "createCurrencyQuote",
+					Summary:     "Lock the current rate of the pair for an exchange",
+					Tags:        []string{"rates"},
+					Security:    bearer,
+					RequestBody: jsonBody(objectSchema(map[string]*openapi.Schema{
+						"base":   openapi.Ref("CurrencyCode"),
+						"second": openapi.Ref("CurrencyCode"),
+						"amount": openapi.Ref("Decimal"),
+					}, "base", "second", "amount")),
+					Responses: responds("201", "Quote", openapi.Ref("Quote")),
+				},
+			},
+			"/currency/change-ban": {
+				"post": {
+					OperationID: "changeCurrencyBanStatus",
+					Summary:     "Open a ban window for the currency or lift its bans",
+					Tags:        []string{"bans"},
+					Security:    bearer,
+					RequestBody: jsonBody(openapi.Ref("BanChange")),
+					Responses: responds("200", "The opened window, or the lifted windows", &openapi.Schema{
+						OneOf: []*openapi.Schema{openapi.Ref("BanWindow"), arraySchema(openapi.Ref("BanWindow"))},
+					}),
+				},
+			},
+			"/currency/change-ban/bulk": {
+				"post": {
+					OperationID: "changeCurrencyBanStatusBulk",
+					Summary:     "Apply several ban changes in one transaction",
+					Tags:        []string{"bans"},
+					Security:    bearer,
+					RequestBody: jsonBody(&openapi.Schema{Type: "array", Items: openapi.Ref("BanChange"), MinItems: 1}),
+					Responses:   responds("200", "Result of every change", arraySchema(openapi.Ref("BanChangeResult"))),
+				},
+			},
+			"/currency/ban-events": {
+				"get": {
+					OperationID: "getBanEvents",
+					Summary:     "Ban events of all currencies, newest first",
+					Tags:        []string{"bans"},
+					Security:    bearer,
+					Parameters:  append([]openapi.Parameter{queryParam("currency", false, openapi.Ref("CurrencyCode"))}, banEventFilters...),
+					Responses:   responds("200", "Ban events", arraySchema(openapi.Ref("BanEvent"))),
+				},
+			},
+			"/currency": {
+				"post": {
+					OperationID: "addCurrency",
+					Summary:     "Add a currency to the catalogue",
+					Tags:        []string{"currencies"},
+					Security:    bearer,
+					RequestBody: jsonBody(openapi.Ref("CurrencyInfo")),
+					Responses:   responds("201", "Catalogue entry", openapi.Ref("Currency")),
+				},
+			},
+			"/currency/{code}": {
+				"get": {
+					OperationID: "getCurrency",
+					Summary:     "Catalogue entry of the currency",
+					Tags:        []string{"currencies"},
+					Parameters:  []openapi.Parameter{currencyPathParam()},
+					Responses:   responds("200", "Catalogue entry", openapi.Ref("Currency")),
+				},
+				"patch": {
+					OperationID: "describeCurrency",
+					Summary:     "Change currency metadata, omitted fields are kept",
+					Tags:        []string{"currencies"},
+					Security:    bearer,
+					Parameters:  []openapi.Parameter{currencyPathParam()},
+					RequestBody: jsonBody(objectSchema(map[string]*openapi.Schema{
+						"numericCode": nullable(patternSchema(`^[0-9]{3}$`)),
+						"nameEn":      nullable(stringSchema()),
+						"nameRu":      nullable(stringSchema()),
+						"symbol":      nullable(stringSchema()),
+						"crypto":      nullable(booleanSchema()),
+						"nonIso":      nullable(booleanSchema()),
+					})),
+					Responses: responds("200", "Catalogue entry", openapi.Ref("Currency")),
+				},
+			},
+			"/currency/{code}/enable": {
+				"post": {
+					OperationID: "enableCurrency",
+					Tags:        []string{"currencies"},
+					Security:    bearer,
+					Parameters:  []openapi.Parameter{currencyPathParam()},
+					Responses:   responds("200", "Catalogue entry", openapi.Ref("Currency")),
+				},
+			},
+			"/currency/{code}/disable": {
+				"post": {
+					OperationID: "disableCurrency",
+					Summary:     "Stop the currency from being used in new operations",
+					Tags:        []string{"currencies"},
+					Security:    bearer,
+					Parameters:  []openapi.Parameter{currencyPathParam()},
+					Responses:   responds("200", "Catalogue entry", openapi.Ref("Currency")),
+				},
+			},
+			"/currency/{code}/ban-history": {
+				"get": {
+					OperationID: "getCurrencyBanHistory",
+					Summary:     "Ban events of the currency, newest first",
+					Tags:        []string{"bans"},
+					Security:    bearer,
+					Parameters:  append([]openapi.Parameter{currencyPathParam()}, banEventFilters...),
+					Responses:   responds("200", "Ban events", arraySchema(openapi.Ref("BanEvent"))),
+				},
+			},
+
+			"/wallets": {
+				"post": {
+					OperationID: "createWallet",
+					Summary:     "Create a wallet, for the caller unless an admin sets the user",
+					Tags:        []string{"wallets"},
+					Security:    bearer,
+					RequestBody: jsonBody(objectSchema(map[string]*openapi.Schema{
+						"userId": stringSchema(),
+					})),
+					Responses: responds("201", "Wallet", openapi.Ref("Wallet")),
+				},
+			},
+			"/wallets/{id}": {
+				"get": {
+					OperationID: "getWallet",
+					Tags:        []string{"wallets"},
+					Security:    bearer,
+					Parameters:  []openapi.Parameter{walletID},
+					Responses:   responds("200", "Wallet with its accounts", openapi.Ref("Wallet")),
+				},
+			},
+			"/wallets/{id}/accounts": {
+				"post": {
+					OperationID: "openWalletAccount",
+					Tags:        []string{"wallets"},
+					Security:    bearer,
+					Parameters:  []openapi.Parameter{walletID},
+					RequestBody: jsonBody(objectSchema(map[string]*openapi.Schema{
+						"currency": openapi.Ref("CurrencyCode"),
+					}, "currency")),
+					Responses: responds("201", "Account", openapi.Ref("Account")),
+				},
+			},
+			"/wallets/{id}/balances": {
+				"get": {
+					OperationID: "getWalletBalances",
+					Tags:        []string{"wallets"},
+					Security:    bearer,
+					Parameters:  []openapi.Parameter{walletID},
+					Responses: responds("200", "Balances", arraySchema(objectSchema(map[string]*openapi.Schema{
+						"currency": openapi.Ref("CurrencyCode"),
+						"balance":  openapi.Ref("Decimal"),
+					}))),
+				},
+			},
+			"/wallets/{id}/deposit": {
+				"post": {
+					OperationID: "depositToWallet",
+					Summary:     "Credit the wallet with money from outside the ledger, admins only",
+					Tags:        []string{"ledger"},
+					Security:    bearer,
+					Parameters:  []openapi.Parameter{walletID},
+					RequestBody: jsonBody(openapi.Ref("MoneyRequest")),
+					Responses:   responds("201", "Posted entry", openapi.Ref("Entry")),
+				},
+			},
+			"/wallets/{id}/withdraw": {
+				"post": {
+					OperationID: "withdrawFromWallet",
+					Summary:     "Debit the wallet with money leaving the ledger, admins only",
+					Tags:        []string{"ledger"},
+					Security:    bearer,
+					Parameters:  []openapi.Parameter{walletID},
+					RequestBody: jsonBody(openapi.Ref("MoneyRequest")),
+					Responses:   responds("201", "Posted entry", openapi.Ref("Entry")),
+				},
+			},
+			"/wallets/{id}/transfer": {
+				"post": {
+					OperationID: "transferFromWallet",
+					Summary:     "Send money to a wallet of any user",
+					Tags:        []string{"ledger"},
+					Security:    bearer,
+					Parameters:  []openapi.Parameter{walletID},
+					RequestBody: jsonBody(objectSchema(map[string]*openapi.Schema{
+						"currency":    openapi.Ref("CurrencyCode"),
+						"amount":      openapi.Ref("Decimal"),
+						"description": stringSchema(),
+						"toWalletId":  positiveIntegerSchema(),
+					}, "currency", "amount", "toWalletId")),
+					Responses: responds("201", "Posted entry", openapi.Ref("Entry")),
+				},
+			},
+			"/wallets/{id}/entries": {
+				"get": {
+					OperationID: "getWalletEntries",
+					Summary:     "Journal entries of the wallet, newest first",
+					Tags:        []string{"ledger"},
+					Security:    bearer,
+					Parameters:  []openapi.Parameter{walletID, queryParam("limit", false, positiveIntegerSchema())},
+					Responses:   responds("200", "Entries", arraySchema(openapi.Ref("Entry"))),
+				},
+			},
+			"/wallets/{id}/exchange": {
+				"post": {
+					OperationID: "exchangeInWallet",
+					Summary:     "Exchange between accounts of the wallet at the current or a quoted rate",
+					Tags:        []string{"wallets"},
+					Security:    bearer,
+					Parameters:  []openapi.Parameter{walletID},
+					RequestBody: jsonBody(objectSchema(map[string]*openapi.Schema{
+						"from":    openapi.Ref("CurrencyCode"),
+						"to":      openapi.Ref("CurrencyCode"),
+						"amount":  openapi.Ref("Decimal"),
+						"quoteId": stringSchema(),
+					})),
+					Responses: responds("201", "Exchange", openapi.Ref("Exchange")),
+				},
+			},
+
+			"/ledger/check": {
+				"get": {
+					OperationID: "checkLedger",
+					Summary:     "Check ledger invariants",
+					Tags:        []string{"ledger"},
+					Security:    bearer,
+					Responses:   responds("200", "Report", openapi.Ref("LedgerReport")),
+				},
+			},
+
+			"/trading-rules": {
+				"get": {
+					OperationID: "getTradingRules",
+					Tags:        []string{"trading rules"},
+					Security:    bearer,
+					Responses:   responds("200", "Rules", arraySchema(openapi.Ref("TradingRule"))),
+				},
+				"post": {
+					OperationID: "createTradingRule",
+					Tags:        []string{"trading rules"},
+					Security:    bearer,
+					RequestBody: jsonBody(openapi.Ref("TradingRule")),
+					Responses:   responds("201", "Rule", openapi.Ref("TradingRule")),
+				},
+			},
+			"/trading-rules/{id}": {
+				"get": {
+					OperationID: "getTradingRule",
+					Tags:        []string{"trading rules"},
+					Security:    bearer,
+					Parameters:  []openapi.Parameter{pathParam("id", positiveIntegerSchema())},
+					Responses:   responds("200", "Rule", openapi.Ref("TradingRule")),
+				},
+				"put": {
+					OperationID: "updateTradingRule",
+					Tags:        []string{"trading rules"},
+					Security:    bearer,
+					Parameters:  []openapi.Parameter{pathParam("id", positiveIntegerSchema())},
+					RequestBody: jsonBody(openapi.Ref("TradingRule")),
+					Responses:   responds("200", "Rule", openapi.Ref("TradingRule")),
+				},
+				"delete": {
+					OperationID: "deleteTradingRule",
+					Tags:        []string{"trading rules"},
+					Security:    bearer,
+					Parameters:  []openapi.Parameter{pathParam("id", positiveIntegerSchema())},
+					Responses:   responds("204", "Deleted", nil),
+				},
+			},
+
+			"/api-keys": {
+				"get": {
+					OperationID: "getAPIKeys",
+					Tags:        []string{"api keys"},
+					Security:    bearer,
+					Responses:   responds("200", "Keys", arraySchema(openapi.Ref("APIKey"))),
+				},
+				"post": {
+					OperationID: "issueAPIKey",
+					Summary:     "Issue a partner key, the key is only returned in this response",
+					Tags:        []string{"api keys"},
+					Security:    bearer,
+					RequestBody: jsonBody(objectSchema(map[string]*openapi.Schema{
+						"name":       stringSchema(),
+						"rateLimit":  {Type: "integer", Minimum: minimum(0), Description: "Requests per minute, 0 for the default"},
+						"dailyQuota": {Type: "integer", Minimum: minimum(0), Description: "Requests per UTC day, 0 for the default"},
+					}, "name")),
+					Responses: responds("201", "Key", openapi.Ref("APIKey")),
+				},
+			},
+			"/api-keys/{id}/revoke": {
+				"post": {
+					OperationID: "revokeAPIKey",
+					Tags:        []string{"api keys"},
+					Security:    bearer,
+					Parameters:  []openapi.Parameter{pathParam("id", positiveIntegerSchema())},
+					Responses:   responds("200", "Key", openapi.Ref("APIKey")),
+				},
+			},
+		},
+		Components: openapi.Components{
+			SecuritySchemes: map[string]openapi.SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				"apiKey":     {Type: "apiKey", Name: "X-API-Key", In: "header"},
+			},
+			Schemas: map[string]*openapi.Schema{
+				"Error": objectSchema(map[string]*openapi.Schema{
+					"code":      stringSchema(),
+					"message":   stringSchema(),
+					"details":   {Description: "Structured context of the error, depends on the code"},
+					"requestId": stringSchema(),
+				}, "code", "message"),
+				"CurrencyCode": patternSchema(`^[A-Z]{3}$`),
+				"Decimal": {
+					Description: "Decimal amount, sent as a string to keep precision",
+					OneOf: []*openapi.Schema{
+						patternSchema(`^-?[0-9]+(\.[0-9]+)?$`),
+						{Type: "number"},
+					},
+				},
+				"CurrencyInfo": objectSchema(map[string]*openapi.Schema{
+					"code":        patternSchema(`^[A-Za-z]{3}$`),
+					"numericCode": patternSchema(`^[0-9]{3}$`),
+					"nameEn":      stringSchema(),
+					"nameRu":      stringSchema(),
+					"symbol":      stringSchema(),
+					"minorUnits":  {Type: "integer", Minimum: minimum(0)},
+					"crypto":      booleanSchema(),
+					"nonIso":      booleanSchema(),
+				}, "code"),
+				"Currency": objectSchema(map[string]*openapi.Schema{
+					"code":        openapi.Ref("CurrencyCode"),
+					"numericCode": stringSchema(),
+					"nameEn":      stringSchema(),
+					"nameRu":      stringSchema(),
+					"symbol":      stringSchema(),
+					"minorUnits":  integerSchema(),
+					"crypto":      booleanSchema(),
+					"nonIso":      booleanSchema(),
+					"enabled":     booleanSchema(),
+					"banned":      booleanSchema(),
+					"ban":         openapi.Ref("BanWindow"),
+					"createdAt":   formatSchema("date-time"),
+					"updatedAt":   formatSchema("date-time"),
+				}),
+				"CurrencyWithBanStatus": objectSchema(map[string]*openapi.Schema{
+					"currency":    openapi.Ref("CurrencyCode"),
+					"banned":      booleanSchema(),
+					"banReason":   stringSchema(),
+					"bannedUntil": formatSchema("date-time"),
+					"info":        openapi.Ref("CurrencyInfo"),
+				}, "currency", "banned"),
+				"CurrencyRate": objectSchema(map[string]*openapi.Schema{
+					"base":   openapi.Ref("CurrencyCode"),
+					"second": openapi.Ref("CurrencyCode"),
+					"rate":   openapi.Ref("Decimal"),
+					"date":   formatSchema("date"),
+					"source": stringSchema(),
+				}, "base", "second", "rate", "date"),
+				"CurrencyTimelineRate": objectSchema(map[string]*openapi.Schema{
+					"base":          openapi.Ref("CurrencyCode"),
+					"second":        openapi.Ref("CurrencyCode"),
+					"rates":         {Type: "object", AdditionalProperties: openapi.Ref("Decimal"), Description: "Rates by date"},
+					"predictions":   openapi.Ref("Forecast"),
+					"predictionJob": openapi.Ref("PredictionJob"),
+					"startDate":     formatSchema("date"),
+					"endDate":       formatSchema("date"),
+					"source":        stringSchema(),
+				}, "base", "second", "rates", "startDate", "endDate"),
+				"Forecast": {
+					Type:        "object",
+					Description: "Predicted rates, omitted when predictions are disabled or not generated yet",
+					Properties: map[string]*openapi.Schema{
+						"model":           stringSchema(),
+						"modelVersion":    stringSchema(),
+						"trainingStart":   formatSchema("date"),
+						"trainingEnd":     formatSchema("date"),
+						"generatedAt":     formatSchema("date-time"),
+						"confidenceLevel": {Type: "number", Description: "Probability the bounds are estimated for"},
+						"points":          arraySchema(openapi.Ref("PredictedRate")),
+					},
+					Required: []string{"model", "trainingStart", "trainingEnd", "generatedAt", "points"},
+				},
+				"PredictedRate": objectSchema(map[string]*openapi.Schema{
+					"date":  formatSchema("date"),
+					"rate":  openapi.Ref("Decimal"),
+					"lower": openapi.Ref("Decimal"),
+					"upper": openapi.Ref("Decimal"),
+				}, "date", "rate"),
+				"PredictionJob": {
+					Type:        "object",
+					Description: "Job generating a forecast, returned with a time series while pending or after failing today",
+					Properties: map[string]*openapi.Schema{
+						"id":         integerSchema(),
+						"base":       openapi.Ref("CurrencyCode"),
+						"second":     openapi.Ref("CurrencyCode"),
+						"model":      stringSchema(),
+						"status":     enumSchema("queued", "running", "done", "failed"),
+						"error":      stringSchema(),
+						"forecast":   openapi.Ref("Forecast"),
+						"createdAt":  formatSchema("date-time"),
+						"startedAt":  formatSchema("date-time"),
+						"finishedAt": formatSchema("date-time"),
+					},
+					Required: []string{"id", "base", "second", "model", "status", "createdAt"},
+				},
+				"PredictionAccuracy": objectSchema(map[string]*openapi.Schema{
+					"base":    openapi.Ref("CurrencyCode"),
+					"second":  openapi.Ref("CurrencyCode"),
+					"model":   stringSchema(),
+					"horizon": {Type: "integer", Description: "Days ahead the rates were predicted, 1 for the day of the forecast"},
+					"count":   integerSchema(),
+					"mae":     openapi.Ref("Decimal"),
+					"mape":    openapi.Ref("Decimal"),
+				}),
+				"Quote": objectSchema(map[string]*openapi.Schema{
+					"id":        stringSchema(),
+					"owner":     stringSchema(),
+					"base":      openapi.Ref("CurrencyCode"),
+					"second":    openapi.Ref("CurrencyCode"),
+					"rate":      openapi.Ref("Decimal"),
+					"amount":    openapi.Ref("Decimal"),
+					"date":      formatSchema("date"),
+					"source":    stringSchema(),
+					"expiresAt": formatSchema("date-time"),
+				}),
+				"BanChange": objectSchema(map[string]*openapi.Schema{
+					"currency": openapi.Ref("CurrencyCode"),
+					"banned":   booleanSchema(),
+					"reason":   stringSchema(),
+					"start":    formatSchema("date-time"),
+					"end":      nullable(formatSchema("date-time")),
+				}, "currency", "banned"),
+				"BanWindow": objectSchema(map[string]*openapi.Schema{
+					"id":        integerSchema(),
+					"currency":  openapi.Ref("CurrencyCode"),
+					"reason":    stringSchema(),
+					"author":    stringSchema(),
+					"startsAt":  formatSchema("date-time"),
+					"endsAt":    formatSchema("date-time"),
+					"liftedAt":  formatSchema("date-time"),
+					"liftedBy":  stringSchema(),
+					"createdAt": formatSchema("date-time"),
+				}),
+				"BanChangeResult": objectSchema(map[string]*openapi.Schema{
+					"currency": openapi.Ref("CurrencyCode"),
+					"banned":   booleanSchema(),
+					"window":   openapi.Ref("BanWindow"),
+					"lifted":   arraySchema(openapi.Ref("BanWindow")),
+					"error":    stringSchema(),
+				}),
+				"BanEvent": objectSchema(map[string]*openapi.Schema{
+					"id":        integerSchema(),
+					"currency":  openapi.Ref("CurrencyCode"),
+					"action":    enumSchema("ban", "lift"),
+					"windowId":  integerSchema(),
+					"reason":    stringSchema(),
+					"author":    stringSchema(),
+					"startsAt":  formatSchema("date-time"),
+					"endsAt":    formatSchema("date-time"),
+					"createdAt": formatSchema("date-time"),
+				}),
+				"Wallet": objectSchema(map[string]*openapi.Schema{
+					"id":        integerSchema(),
+					"userId":    stringSchema(),
+					"createdAt": formatSchema("date-time"),
+					"accounts":  arraySchema(openapi.Ref("Account")),
+				}),
+				"Account": objectSchema(map[string]*openapi.Schema{
+					"id":        integerSchema(),
+					"walletId":  integerSchema(),
+					"currency":  openapi.Ref("CurrencyCode"),
+					"balance":   openapi.Ref("Decimal"),
+					"createdAt": formatSchema("date-time"),
+				}),
+				"MoneyRequest": objectSchema(map[string]*openapi.Schema{
+					"currency":    openapi.Ref("CurrencyCode"),
+					"amount":      openapi.Ref("Decimal"),
+					"description": stringSchema(),
+				}, "currency", "amount"),
+				"Entry": objectSchema(map[string]*openapi.Schema{
+					"id":          integerSchema(),
+					"kind":        stringSchema(),
+					"description": stringSchema(),
+					"createdAt":   formatSchema("date-time"),
+					"postings": arraySchema(objectSchema(map[string]*openapi.Schema{
+						"id":        integerSchema(),
+						"entryId":   integerSchema(),
+						"accountId": integerSchema(),
+						"currency":  openapi.Ref("CurrencyCode"),
+						"amount":    openapi.Ref("Decimal"),
+					})),
+				}),
+				"Exchange": objectSchema(map[string]*openapi.Schema{
+					"id":            integerSchema(),
+					"walletId":      integerSchema(),
+					"entryId":       integerSchema(),
+					"from":          openapi.Ref("CurrencyCode"),
+					"to":            openapi.Ref("CurrencyCode"),
+					"fromAmount":    openapi.Ref("Decimal"),
+					"toAmount":      openapi.Ref("Decimal"),
+					"rate":          openapi.Ref("Decimal"),
+					"effectiveRate": openapi.Ref("Decimal"),
+					"spread":        openapi.Ref("Decimal"),
+					"fee":           openapi.Ref("Decimal"),
+					"rateDate":      formatSchema("date-time"),
+					"rateSource":    stringSchema(),
+					"createdAt":     formatSchema("date-time"),
+				}),
+				"LedgerReport": objectSchema(map[string]*openapi.Schema{
+					"ok": booleanSchema(),
+					"unbalancedEntries": arraySchema(objectSchema(map[string]*openapi.Schema{
+						"entryId":  integerSchema(),
+						"currency": openapi.Ref("CurrencyCode"),
+						"sum":      openapi.Ref("Decimal"),
+					})),
+					"mismatchedAccounts": arraySchema(objectSchema(map[string]*openapi.Schema{
+						"accountId":     integerSchema(),
+						"currency":      openapi.Ref("CurrencyCode"),
+						"balance":       openapi.Ref("Decimal"),
+						"ledgerBalance": openapi.Ref("Decimal"),
+					})),
+					"overdrawnAccounts": arraySchema(objectSchema(map[string]*openapi.Schema{
+						"accountId": integerSchema(),
+						"currency":  openapi.Ref("CurrencyCode"),
+						"balance":   openapi.Ref("Decimal"),
+					})),
+				}),
+				"TradingRule": objectSchema(map[string]*openapi.Schema{
+					"id":        integerSchema(),
+					"from":      patternSchema(`^([A-Z]{3})?$`),
+					"to":        patternSchema(`^([A-Z]{3})?$`),
+					"kind":      enumSchema("block", "cap"),
+					"maxAmount": nullable(openapi.Ref("Decimal")),
+					"reason":    stringSchema(),
+					"createdAt": formatSchema("date-time"),
+					"updatedAt": formatSchema("date-time"),
+				}, "kind"),
+				"APIKey": objectSchema(map[string]*openapi.Schema{
+					"id":         integerSchema(),
+					"name":       stringSchema(),
+					"prefix":     stringSchema(),
+					"key":        {Type: "string", Description: "The key itself, only returned when issued"},
+					"rateLimit":  integerSchema(),
+					"dailyQuota": integerSchema(),
+					"createdAt":  formatSchema("date-time"),
+					"revokedAt":  formatSchema("date-time"),
+				}),
+			},
+		},
+	}
+}
+
+func stringSchema() *openapi.Schema {
+	return &openapi.Schema{Type: "string"}
+}
+
+func patternSchema(pattern string) *openapi.Schema {
+	return &openapi.Schema{Type: "string", Pattern: pattern}
+}
+
+func formatSchema(format string) *openapi.Schema {
+	return &openapi.Schema{Type: "string", Format: format}
+}
+
+func enumSchema(values ...string) *openapi.Schema {
+	return &openapi.Schema{Type: "string", Enum: values}
+}
+
+func integerSchema() *openapi.Schema {
+	return &openapi.Schema{Type: "integer"}
+}
+
+func positiveIntegerSchema() *openapi.Schema {
+	return &openapi.Schema{Type: "integer", Minimum: minimum(1)}
+}
+
+func booleanSchema() *openapi.Schema {
+	return &openapi.Schema{Type: "boolean"}
+}
+
+func objectSchema(properties map[string]*openapi.Schema, required ...string) *openapi.Schema {
+	return &openapi.Schema{Type: "object", Properties: properties, Required: required}
+}
+
+func arraySchema(items *openapi.Schema) *openapi.Schema {
+	return &openapi.Schema{Type: "array", Items: items}
+}
+
+// nullable lets the field be sent as null, a reference is wrapped since siblings of $ref are ignored.
+func nullable(schema *openapi.Schema) *openapi.Schema {
+	if schema.Ref != "" {
+		return &openapi.Schema{Nullable: true, OneOf: []*openapi.Schema{schema}}
+	}
+	schema.Nullable = true
+	return schema
+}
+
+func minimum(value float64) *float64 {
+	return &value
+}
+
+func queryParam(name string, required bool, schema *openapi.Schema) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "query", Required: required, Schema: schema}
+}
+
+func pathParam(name string, schema *openapi.Schema) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "path", Required: true, Schema: schema}
+}
+
+func currencyPathParam() openapi.Parameter {
+	return pathParam("code", patternSchema(`^[A-Za-z]{3}$`))
+}
+
+func jsonBody(schema *openapi.Schema) *openapi.RequestBody {
+	return &openapi.RequestBody{Required: true, Content: openapi.JSON(schema)}
+}
+
+// responds documents the successful response and the Error body of the failed ones.
+func responds(status, description string, schema *openapi.Schema) map[string]openapi.Response {
+	success := openapi.Response{Description: description}
+	if schema != nil {
+		success.Content = openapi.JSON(schema)
+	}
+	return map[string]openapi.Response{
+		status: success,
+		"default": {
+			Description: "Error",
+			Content:     openapi.JSON(openapi.Ref("Error")),
+		},
+	}
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TestAPISpecMatchesRoutes checks that every route is described, the validation middleware skips undocumented ones.
+func TestAPISpecMatchesRoutes(t *testing.T) {
+	r := chi.NewRouter()
+	initRoutes(r, &HttpService{})
+
+	err := newAPISpec().CheckRoutes(r)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidateRequestsBodySize(t *testing.T) {
+	r := chi.NewRouter()
+	initRoutes(r, &HttpService{})
+
+	tests := []struct {
+		name       string
+		body       string
+		wantCode   int
+		wantCalled bool
+	}{
+		{"within the limit", `{"base":"USD","second":"EUR","amount":"10"}`, http.StatusOK, true},
+		{"over the limit", `{"base":"USD","second":"EUR","amount":"10` + strings.Repeat("0", 64) + `"}`,
+			http.StatusRequestEntityTooLarge, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := validateRequests(newAPISpec(), r, 64)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/currency/quote", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("got status %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			if called != tt.wantCalled {
+				t.Errorf("got handler called %v, want %v", called, tt.wantCalled)
+			}
+		})
+	}
+}
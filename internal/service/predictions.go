@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"wallet-service/internal/accuracy"
+	"wallet-service/internal/auth"
+	"wallet-service/internal/currency_helpers"
+	"wallet-service/internal/jobs"
+	"wallet-service/internal/predictor"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+)
+
+// timelineResponse is a time series with the job generating its predictions while they are not ready.
+type timelineResponse struct {
+	currency_helpers.CurrencyTimelineRate
+	PredictionJob *jobs.Job `json:"predictionJob,omitempty"`
+}
+
+// forecast returns the cached forecast of the model for the pair, or enqueues a job generating it,
+// so the time series is served right away and the predictions follow once the job is done.
+// Only authenticated callers enqueue, anonymous ones get the job a user already enqueued.
+// The job is returned without a forecast while pending or after failing today,
+// neither is returned when there is no job or the queue is unavailable.
+func (s *HttpService) forecast(
+	ctx context.Context,
+	ratePredictor predictor.Predictor,
+	base currency_helpers.CurrencyCode,
+	second currency_helpers.CurrencyCode,
+) (*currency_helpers.Forecast, *jobs.Job) {
+	cacheCtx, cancel := context.WithTimeout(ctx, s.cfg.CacheTimeout)
+	defer cancel()
+	forecast, err := s.redisCache.GetForecast(cacheCtx, base, second, ratePredictor.Name())
+	if err != nil {
+		log.Printf("error in get forecast from cache: %s", err.Error())
+	}
+	if forecast != nil {
+		return forecast, nil
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, s.cfg.DBTimeout)
+	defer cancel()
+	var job *jobs.Job
+	if _, ok := auth.FromContext(ctx); ok {
+		job, err = s.predictionJobs.Enqueue(dbCtx, base, second, ratePredictor.Name())
+	} else {
+		job, err = s.predictionJobs.Find(dbCtx, base, second, ratePredictor.Name())
+	}
+	if err != nil {
+		log.Printf("error in get prediction job: %s", err.Error())
+		return nil, nil
+	}
+	if job == nil {
+		return nil, nil
+	}
+	// the forecast of a job done today may have left the cache
+	if job.Status == jobs.StatusDone && job.Forecast != nil {
+		return job.Forecast, nil
+	}
+
+	return nil, job
+}
+
+// runPredictionJob predicts the rates of the job pair starting today. Forecasts are cached until
+// the end of the day and recorded to be compared with the realized rates.
+func (s *HttpService) runPredictionJob(ctx context.Context, job jobs.Job) (*currency_helpers.Forecast, error) {
+	ratePredictor, ok := s.predictors.Get(job.Model)
+	if !ok {
+		return nil, errors.Errorf("unknown prediction model '%s'", job.Model)
+	}
+
+	rate, err := s.timelineRates(ctx, job.Base, job.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	forecast, err := ratePredictor.Predict(ctx, rate.Rates, now)
+	if err != nil {
+		if errors.Is(err, predictor.ErrNotEnoughHistory) || errors.Is(err, predictor.ErrNotFinite) {
+			return nil, err
+		}
+		return nil, errors.Wrapf(errPredictionFailed, "%s: %s", ratePredictor.Name(), err.Error())
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, s.cfg.DBTimeout)
+	defer cancel()
+	err = s.accuracy.Record(dbCtx, job.Base, job.Second, forecast)
+	if err != nil {
+		log.Printf("error in record predictions: %s", err.Error())
+	}
+
+	year, month, day := now.Date()
+	expiresAt := time.Date(year, month, day+1, 0, 0, 0, 0, now.Location())
+	cacheCtx, cancel := context.WithTimeout(ctx, s.cfg.CacheTimeout)
+	defer cancel()
+	err = s.redisCache.SaveForecast(cacheCtx, job.Base, job.Second, forecast, expiresAt)
+	if err != nil {
+		log.Printf("error in save forecast: %s", err.Error())
+	}
+
+	return forecast, nil
+}
+
+// CreatePredictionJob enqueues generating the forecast of the pair, the default model is used
+// when none is given. A job queued, running or finished today for the pair and model is returned instead.
+func (s *HttpService) CreatePredictionJob(w http.ResponseWriter, r *http.Request) {
+	req := struct {
+		Base   currency_helpers.CurrencyCode `json:"base"`
+		Second currency_helpers.CurrencyCode `json:"second"`
+		Model  string                        `json:"model"`
+	}{}
+	defer r.Body.Close()
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, r, invalidBody(err))
+		return
+	}
+
+	if s.predictors == nil {
+		writeError(w, r, errPredictionsDisabled)
+		return
+	}
+	if !s.catalogue.Supported(req.Base) {
+		writeError(w, r, invalidCurrency("invalid base currency code"))
+		return
+	}
+	if !s.catalogue.Supported(req.Second) {
+		writeError(w, r, invalidCurrency("invalid second currency code"))
+		return
+	}
+	err = s.tradingRules.CheckPair(req.Base, req.Second)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	ratePredictor, ok := s.predictors.Get(req.Model)
+	if !ok {
+		writeError(w, r, badRequest(fmt.Sprintf("unknown prediction model '%s'", req.Model)))
+		return
+	}
+
+	dbCtx, cancel := context.WithTimeout(r.Context(), s.cfg.DBTimeout)
+	defer cancel()
+	job, err := s.predictionJobs.Enqueue(dbCtx, req.Base, req.Second, ratePredictor.Name())
+	if err != nil {
+		writeError(w, r, errors.Wrap(err, "error in enqueue prediction job"))
+		return
+	}
+
+	writeJSON(w, r, http.StatusAccepted, job)
+}
+
+// GetPredictionJob returns the status of the job, with the forecast once it is done.
+func (s *HttpService) GetPredictionJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, badRequest("invalid prediction job id"))
+		return
+	}
+
+	dbCtx, cancel := context.WithTimeout(r.Context(), s.cfg.DBTimeout)
+	defer cancel()
+	job, err := s.predictionJobs.Get(dbCtx, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, job)
+}
+
+// GetPredictionAccuracy reports errors of past predictions by pair, model and horizon,
+// optionally filtered by the base, second and model query params.
+func (s *HttpService) GetPredictionAccuracy(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := accuracy.Filter{
+		Base:   currency_helpers.CurrencyCode(query.Get("base")),
+		Second: currency_helpers.CurrencyCode(query.Get("second")),
+		Model:  query.Get("model"),
+	}
+
+	dbCtx, cancel := context.WithTimeout(r.Context(), s.cfg.DBTimeout)
+	defer cancel()
+	report, err := s.accuracy.Report(dbCtx, filter)
+	if err != nil {
+		writeError(w, r, errors.Wrap(err, "error in get prediction accuracy"))
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, report)
+}
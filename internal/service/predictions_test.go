@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"wallet-service/internal/auth"
+	"wallet-service/internal/config"
+	"wallet-service/internal/currency_helpers"
+	"wallet-service/internal/jobs"
+	"wallet-service/internal/predictor"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// fakePredictor fails with err, or forecasts nothing when it is nil.
+type fakePredictor struct {
+	err error
+}
+
+func (p *fakePredictor) Name() string {
+	return "fake"
+}
+
+func (p *fakePredictor) Version() string {
+	return "test"
+}
+
+func (p *fakePredictor) Predict(
+	_ context.Context,
+	_ map[currency_helpers.CustomTime]currency_helpers.Decimal,
+	from time.Time,
+) (*currency_helpers.Forecast, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &currency_helpers.Forecast{Model: p.Name(), ModelVersion: p.Version(), GeneratedAt: from}, nil
+}
+
+// fakeModels serves a single predictor as the default model.
+type fakeModels struct {
+	predictor predictor.Predictor
+}
+
+func (m *fakeModels) Get(name string) (predictor.Predictor, bool) {
+	if name != "" && name != m.predictor.Name() {
+		return nil, false
+	}
+	return m.predictor, true
+}
+
+func testTimeline(t *testing.T) *currency_helpers.CurrencyTimelineRate {
+	rates := make(map[currency_helpers.CustomTime]currency_helpers.Decimal)
+	for i, rate := range []string{"0.90", "0.91", "0.92", "0.93"} {
+		rates[currency_helpers.CustomTime{Time: time.Date(2023, 5, 1+i, 0, 0, 0, 0, time.UTC)}] = decimal(t, rate)
+	}
+	return &currency_helpers.CurrencyTimelineRate{Base: "USD", Second: "EUR", Rates: rates, Source: "cache"}
+}
+
+// TestRunPredictionJobFailure fails the job of a predictor returning an error,
+// the fake cache and the nil accuracy tracker panic if the job goes on to save the forecast.
+func TestRunPredictionJobFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantCause error
+	}{
+		{"predictor error", errors.New("connection reset"), errPredictionFailed},
+		{"not enough history", errors.Wrap(predictor.ErrNotEnoughHistory, "2 rates"), predictor.ErrNotEnoughHistory},
+		{"not finite", predictor.ErrNotFinite, predictor.ErrNotFinite},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &HttpService{
+				redisCache: &fakeCache{timeline: testTimeline(t)},
+				predictors: &fakeModels{&fakePredictor{err: tt.err}},
+				cfg:        &config.Config{CacheTimeout: time.Second, DBTimeout: time.Second},
+			}
+
+			forecast, err := s.runPredictionJob(context.Background(), jobs.Job{
+				ID: 1, Base: "USD", Second: "EUR", Model: "fake",
+			})
+			if forecast != nil {
+				t.Errorf("got forecast %+v", forecast)
+			}
+			// the cause is the error the failed job shows to clients
+			if errors.Cause(err) != tt.wantCause {
+				t.Errorf("got error %v, want cause %v", err, tt.wantCause)
+			}
+		})
+	}
+}
+
+// TestGetTimelineCurrencyRateWithoutPredictions serves the time series without predictions
+// when they are disabled or their job cannot be enqueued.
+func TestGetTimelineCurrencyRateWithoutPredictions(t *testing.T) {
+	// the queue fails to connect on the first query
+	db, err := sqlx.Open("postgres", "host=/nonexistent dbname=wallet sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	cfg := &config.Config{CacheTimeout: time.Second, DBTimeout: time.Second}
+
+	const want = `{"base":"USD","second":"EUR","rates":{"2023-05-02T00:00:00Z":"0.91","2023-05-03T00:00:00Z":"0.92"},` +
+		`"startDate":"2023-05-02","endDate":"2023-05-04","source":"cache"}` + "\n"
+
+	tests := []struct {
+		name       string
+		predictors predictionModels
+	}{
+		{"disabled", nil},
+		{"queue unavailable", &fakeModels{&fakePredictor{err: errors.New("connection reset")}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &HttpService{
+				redisCache:     &fakeCache{timeline: testTimeline(t)},
+				catalogue:      testCatalogue(),
+				tradingRules:   &fakeTradingRules{},
+				predictors:     tt.predictors,
+				predictionJobs: jobs.InitQueue(db, cfg),
+				cfg:            cfg,
+			}
+
+			w := httptest.NewRecorder()
+			s.GetTimelineCurrencyRate(w, httptest.NewRequest(
+				http.MethodGet, "/currency/time-series?base=USD&second=EUR&start=2023-05-02&end=2023-05-04", nil,
+			))
+
+			if w.Code != http.StatusOK {
+				t.Errorf("got status %d, want %d", w.Code, http.StatusOK)
+			}
+			if w.Body.String() != want {
+				t.Errorf("got body %s, want %s", w.Body.String(), want)
+			}
+		})
+	}
+}
+
+// fakeQueue holds the job of the pair, Enqueue adds a queued one when there is none.
+type fakeQueue struct {
+	predictionQueue
+	job      *jobs.Job
+	enqueued bool
+}
+
+func (q *fakeQueue) Enqueue(
+	_ context.Context,
+	base, second currency_helpers.CurrencyCode,
+	model string,
+) (*jobs.Job, error) {
+	q.enqueued = true
+	if q.job == nil {
+		q.job = &jobs.Job{ID: 1, Base: base, Second: second, Model: model, Status: jobs.StatusQueued}
+	}
+	return q.job, nil
+}
+
+func (q *fakeQueue) Find(_ context.Context, _, _ currency_helpers.CurrencyCode, _ string) (*jobs.Job, error) {
+	return q.job, nil
+}
+
+// TestGetTimelineCurrencyRatePredictionJob enqueues prediction jobs for users only,
+// anonymous callers get the job or the forecast a user's request generated.
+func TestGetTimelineCurrencyRatePredictionJob(t *testing.T) {
+	forecast := &currency_helpers.Forecast{Model: "fake"}
+	tests := []struct {
+		name         string
+		principal    *auth.Principal
+		job          *jobs.Job
+		wantEnqueued bool
+		wantJob      bool
+		wantForecast bool
+	}{
+		{name: "anonymous without a job"},
+		{
+			name:    "anonymous with a queued job",
+			job:     &jobs.Job{ID: 7, Status: jobs.StatusQueued},
+			wantJob: true,
+		},
+		{
+			name:         "anonymous with a job done today",
+			job:          &jobs.Job{ID: 7, Status: jobs.StatusDone, Forecast: forecast},
+			wantForecast: true,
+		},
+		{
+			name:         "user without a job",
+			principal:    &auth.Principal{Subject: "alice", Roles: []auth.Role{auth.RoleUser}},
+			wantEnqueued: true,
+			wantJob:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := &fakeQueue{job: tt.job}
+			s := &HttpService{
+				redisCache:     &fakeCache{timeline: testTimeline(t)},
+				catalogue:      testCatalogue(),
+				tradingRules:   &fakeTradingRules{},
+				predictors:     &fakeModels{&fakePredictor{}},
+				predictionJobs: queue,
+				cfg:            &config.Config{CacheTimeout: time.Second, DBTimeout: time.Second},
+			}
+
+			r := httptest.NewRequest(
+				http.MethodGet, "/currency/time-series?base=USD&second=EUR&start=2023-05-02&end=2023-05-04", nil,
+			)
+			if tt.principal != nil {
+				r = r.WithContext(auth.WithPrincipal(r.Context(), tt.principal))
+			}
+			w := httptest.NewRecorder()
+			s.GetTimelineCurrencyRate(w, r)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+			}
+			body := struct {
+				Predictions   *currency_helpers.Forecast `json:"predictions"`
+				PredictionJob *jobs.Job                  `json:"predictionJob"`
+			}{}
+			err := json.Unmarshal(w.Body.Bytes(), &body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if queue.enqueued != tt.wantEnqueued {
+				t.Errorf("got enqueued %v, want %v", queue.enqueued, tt.wantEnqueued)
+			}
+			if (body.PredictionJob != nil) != tt.wantJob {
+				t.Errorf("got prediction job %+v, want one %v", body.PredictionJob, tt.wantJob)
+			}
+			if (body.Predictions != nil) != tt.wantForecast {
+				t.Errorf("got predictions %+v, want them %v", body.Predictions, tt.wantForecast)
+			}
+		})
+	}
+}
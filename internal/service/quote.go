@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+	"wallet-service/internal/apierror"
+	"wallet-service/internal/auth"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/pkg/errors"
+)
+
+func (s *HttpService) CreateCurrencyQuote(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := struct {
+		Base   currency_helpers.CurrencyCode `json:"base"`
+		Second currency_helpers.CurrencyCode `json:"second"`
+		Amount currency_helpers.Decimal      `json:"amount"`
+	}{}
+	defer r.Body.Close()
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, r, invalidBody(err))
+		return
+	}
+
+	if !s.catalogue.Supported(req.Base) {
+		writeError(w, r, invalidCurrency("invalid base currency code"))
+		return
+	}
+	if !s.catalogue.Supported(req.Second) {
+		writeError(w, r, invalidCurrency("invalid second currency code"))
+		return
+	}
+	if req.Base == req.Second {
+		writeError(w, r, badRequest("cannot quote currency to itself"))
+		return
+	}
+
+	err = s.catalogue.Money(req.Amount, req.Base).Validate()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	for _, currency := range []currency_helpers.CurrencyCode{req.Base, req.Second} {
+		if s.catalogue.IsBanned(currency) {
+			writeError(w, r, apierror.Errorf(
+				http.StatusUnprocessableEntity, apierror.CodeCurrencyBanned, "currency '%s' is banned", currency,
+			))
+			return
+		}
+	}
+
+	err = s.tradingRules.CheckTrade(req.Base, req.Second, req.Amount)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	currencyRate, err := s.currentRate(ctx, req.Base, req.Second)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	id, err := newQuoteID()
+	if err != nil {
+		writeError(w, r, errors.Wrap(err, "error in generate quote id"))
+		return
+	}
+
+	principal, _ := auth.FromContext(ctx)
+	quote := &currency_helpers.Quote{
+		ID:        id,
+		Owner:     principal.Subject,
+		Base:      currencyRate.Base,
+		Second:    currencyRate.Second,
+		Rate:      currencyRate.Rate,
+		Amount:    req.Amount,
+		Date:      currencyRate.Date,
+		Source:    currencyRate.Source,
+		ExpiresAt: time.Now().Add(s.cfg.QuoteTTL).UTC(),
+	}
+
+	cacheCtx, cancel := context.WithTimeout(ctx, s.cfg.CacheTimeout)
+	defer cancel()
+	err = s.redisCache.SaveQuote(cacheCtx, quote)
+	if err != nil {
+		writeError(w, r, cacheError(err, "error in save quote"))
+		return
+	}
+
+	writeJSON(w, r, http.StatusCreated, quote)
+}
+
+func newQuoteID() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
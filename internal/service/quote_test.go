@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"wallet-service/internal/auth"
+	"wallet-service/internal/config"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+func withPrincipal(r *http.Request, subject string) *http.Request {
+	principal := &auth.Principal{Subject: subject, Roles: []auth.Role{auth.RoleUser}}
+	return r.WithContext(auth.WithPrincipal(r.Context(), principal))
+}
+
+func TestCreateCurrencyQuoteOwner(t *testing.T) {
+	published := latestPublication(time.Now())
+	redisCache := &fakeCache{rate: &currency_helpers.CurrencyRate{
+		Base: "USD", Second: "EUR", Rate: decimal(t, "0.91"), Date: currency_helpers.CustomTime{Time: published},
+	}}
+	s := &HttpService{
+		redisCache:   redisCache,
+		catalogue:    testCatalogue(),
+		tradingRules: &fakeTradingRules{},
+		cfg:          &config.Config{CacheTimeout: time.Second, QuoteTTL: time.Minute},
+	}
+
+	w := httptest.NewRecorder()
+	s.CreateCurrencyQuote(w, withPrincipal(httptest.NewRequest(
+		http.MethodPost, "/currency/quote", strings.NewReader(`{"base":"USD","second":"EUR","amount":"10"}`),
+	), "alice"))
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("got status %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	if redisCache.quote == nil || redisCache.quote.Owner != "alice" {
+		t.Errorf("got quote %+v saved, want one owned by alice", redisCache.quote)
+	}
+}
+
+// TestExchangeInWalletQuoteOwner rejects quotes of other users as missing before the wallet is looked up.
+func TestExchangeInWalletQuoteOwner(t *testing.T) {
+	// the wallet lookup fails to connect, so an accepted quote ends in an internal error
+	db, err := sqlx.Open("postgres", "host=/nonexistent dbname=wallet sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tests := []struct {
+		name     string
+		owner    string
+		caller   string
+		wantCode int
+	}{
+		{"owner", "alice", "alice", http.StatusInternalServerError},
+		{"another user", "alice", "bob", http.StatusGone},
+		{"quote without an owner", "", "alice", http.StatusGone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &HttpService{
+				db: db,
+				redisCache: &fakeCache{quote: &currency_helpers.Quote{
+					ID:        "q1",
+					Owner:     tt.owner,
+					Base:      "USD",
+					Second:    "EUR",
+					Rate:      decimal(t, "0.91"),
+					Amount:    decimal(t, "10"),
+					ExpiresAt: time.Now().Add(time.Minute),
+				}},
+				catalogue:    testCatalogue(),
+				tradingRules: &fakeTradingRules{},
+				cfg:          &config.Config{CacheTimeout: time.Second, DBTimeout: time.Second},
+			}
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "1")
+			r := httptest.NewRequest(http.MethodPost, "/wallets/1/exchange", strings.NewReader(`{"quoteId":"q1"}`))
+			r = withPrincipal(r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx)), tt.caller)
+			w := httptest.NewRecorder()
+			s.ExchangeInWallet(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("got status %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			if tt.wantCode == http.StatusGone && !strings.Contains(w.Body.String(), `"code":"quote_not_found"`) {
+				t.Errorf("got body %s", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"wallet-service/internal/apierror"
+
+	"github.com/pkg/errors"
+)
+
+// writeJSON renders the value with the status. The value is marshalled before anything is written,
+// so a marshalling failure is still reported as an error response instead of a broken body.
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, value interface{}) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		writeError(w, r, errors.Wrap(err, "error in marshalling response"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(append(body, '\n'))
+	if err != nil {
+		log.Printf("error in write response: %s", err.Error())
+	}
+}
+
+func writeNoContent(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// negotiate rejects requests whose Accept header rules out JSON, the only representation
+// the service renders, before any handler runs.
+func negotiate(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !acceptsJSON(r.Header.Values("Accept")) {
+			writeError(w, r, apierror.New(
+				http.StatusNotAcceptable, apierror.CodeNotAcceptable, "only application/json responses are available",
+			))
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// acceptsJSON reports whether the Accept header values allow application/json,
+// a missing header accepts anything.
+func acceptsJSON(accept []string) bool {
+	if len(accept) == 0 {
+		return true
+	}
+
+	for _, value := range accept {
+		for _, mediaRange := range strings.Split(value, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+			if err != nil {
+				continue
+			}
+			// q=0 explicitly refuses the media type
+			if q, ok := params["q"]; ok {
+				weight, err := strconv.ParseFloat(q, 64)
+				if err != nil || weight <= 0 {
+					continue
+				}
+			}
+			switch mediaType {
+			case "application/json", "application/*", "*/*":
+				return true
+			}
+		}
+	}
+	return false
+}
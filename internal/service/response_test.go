@@ -0,0 +1,134 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		value       interface{}
+		wantStatus  int
+		wantBody    string
+		wantNoSniff bool
+	}{
+		{
+			name:       "value",
+			status:     http.StatusCreated,
+			value:      map[string]int{"id": 1},
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"id":1}` + "\n",
+		},
+		{
+			name:       "nil",
+			status:     http.StatusOK,
+			wantStatus: http.StatusOK,
+			wantBody:   "null\n",
+		},
+		{
+			// the status is not written before marshalling, so the failure gets an error response
+			name:        "unmarshallable",
+			status:      http.StatusOK,
+			value:       make(chan int),
+			wantStatus:  http.StatusInternalServerError,
+			wantBody:    `{"code":"internal_error","message":"internal error"}` + "\n",
+			wantNoSniff: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writeJSON(w, httptest.NewRequest(http.MethodGet, "/", nil), tt.status, tt.value)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("got status %d, want %d", w.Code, tt.wantStatus)
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+				t.Errorf("got Content-Type %q", contentType)
+			}
+			if noSniff := w.Header().Get("X-Content-Type-Options") == "nosniff"; noSniff != tt.wantNoSniff {
+				t.Errorf("got nosniff %v, want %v", noSniff, tt.wantNoSniff)
+			}
+			if w.Body.String() != tt.wantBody {
+				t.Errorf("got body %q, want %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestWriteNoContent(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeNoContent(w)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if len(w.Header()) != 0 {
+		t.Errorf("got headers %v", w.Header())
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("got body %q", w.Body.String())
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	const notAcceptable = `{"code":"not_acceptable","message":"only application/json responses are available"}` + "\n"
+
+	tests := []struct {
+		name   string
+		accept []string
+		want   int
+	}{
+		{"missing", nil, http.StatusOK},
+		{"json", []string{"application/json"}, http.StatusOK},
+		{"json with charset", []string{"application/json; charset=utf-8"}, http.StatusOK},
+		{"any", []string{"*/*"}, http.StatusOK},
+		{"any application", []string{"text/html, application/*;q=0.5"}, http.StatusOK},
+		{"second header", []string{"text/html", "application/json"}, http.StatusOK},
+		{"json refused, anything else accepted", []string{"application/json;q=0, */*;q=0.1"}, http.StatusOK},
+		{"json refused", []string{"application/json;q=0"}, http.StatusNotAcceptable},
+		{"json refused with decimals", []string{"application/json;q=0.000"}, http.StatusNotAcceptable},
+		{"invalid weight", []string{"application/json;q=high"}, http.StatusNotAcceptable},
+		{"html only", []string{"text/html"}, http.StatusNotAcceptable},
+		{"malformed", []string{"json"}, http.StatusNotAcceptable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := negotiate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				writeNoContent(w)
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for _, value := range tt.accept {
+				r.Header.Add("Accept", value)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if tt.want == http.StatusOK {
+				if !called || w.Code != http.StatusNoContent {
+					t.Errorf("handler called %v with status %d, want it to run", called, w.Code)
+				}
+				return
+			}
+
+			if called {
+				t.Error("handler called")
+			}
+			if w.Code != tt.want {
+				t.Errorf("got status %d, want %d", w.Code, tt.want)
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+				t.Errorf("got Content-Type %q", contentType)
+			}
+			if w.Body.String() != notAcceptable {
+				t.Errorf("got body %q, want %q", w.Body.String(), notAcceptable)
+			}
+		})
+	}
+}
@@ -1,15 +1,84 @@
 package service
 
 import (
+	"wallet-service/internal/auth"
+
 	"github.com/go-chi/chi/v5"
 )
 
 func initRoutes(r chi.Router, s Service) {
+	requireUser := requireRole(auth.RoleUser, auth.RoleAdmin)
+	requireAdmin := requireRole(auth.RoleAdmin)
+
+	r.Get("/openapi.json", s.GetOpenAPI)
+
 	r.Route("/currency", func(r chi.Router) {
 		r.Get("/available", s.GetAvailableCurrencies)
-		r.Post("/change-ban", s.ChangeCurrencyBanStatus)
-
 		r.Get("/current-rate", s.GetCurrentCurrencyRate)
 		r.Get("/time-series", s.GetTimelineCurrencyRate)
+		r.Get("/predictions/jobs/{id}", s.GetPredictionJob)
+		r.Get("/{code}", s.GetCurrency)
+
+		r.With(requireUser).Post("/quote", s.CreateCurrencyQuote)
+		r.With(requireUser).Post("/predictions/jobs", s.CreatePredictionJob)
+
+		r.Group(func(r chi.Router) {
+			r.Use(requireAdmin)
+
+			r.Post("/change-ban", s.ChangeCurrencyBanStatus)
+			r.Post("/change-ban/bulk", s.ChangeCurrencyBanStatusBulk)
+			r.Get("/ban-events", s.GetBanEvents)
+			r.Get("/predictions/accuracy", s.GetPredictionAccuracy)
+
+			r.Post("/", s.AddCurrency)
+			r.Patch("/{code}", s.DescribeCurrency)
+			r.Post("/{code}/enable", s.EnableCurrency)
+			r.Post("/{code}/disable", s.DisableCurrency)
+			r.Get("/{code}/ban-history", s.GetCurrencyBanHistory)
+		})
+	})
+
+	r.Route("/wallets", func(r chi.Router) {
+		r.Use(requireUser)
+
+		r.Post("/", s.CreateWallet)
+
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", s.GetWallet)
+			r.Post("/accounts", s.OpenWalletAccount)
+			r.Get("/balances", s.GetWalletBalances)
+
+			// money only enters and leaves the ledger through admins
+			r.With(requireAdmin).Post("/deposit", s.DepositToWallet)
+			r.With(requireAdmin).Post("/withdraw", s.WithdrawFromWallet)
+			r.Post("/transfer", s.TransferFromWallet)
+			r.Get("/entries", s.GetWalletEntries)
+
+			r.Post("/exchange", s.ExchangeInWallet)
+		})
+	})
+
+	r.Route("/ledger", func(r chi.Router) {
+		r.Use(requireAdmin)
+
+		r.Get("/check", s.CheckLedger)
+	})
+
+	r.Route("/trading-rules", func(r chi.Router) {
+		r.Use(requireAdmin)
+
+		r.Get("/", s.GetTradingRules)
+		r.Post("/", s.CreateTradingRule)
+		r.Get("/{id}", s.GetTradingRule)
+		r.Put("/{id}", s.UpdateTradingRule)
+		r.Delete("/{id}", s.DeleteTradingRule)
+	})
+
+	r.Route("/api-keys", func(r chi.Router) {
+		r.Use(requireAdmin)
+
+		r.Get("/", s.GetAPIKeys)
+		r.Post("/", s.IssueAPIKey)
+		r.Post("/{id}/revoke", s.RevokeAPIKey)
 	})
 }
@@ -1,18 +1,24 @@
 package service
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"sort"
 	"time"
+	"wallet-service/internal/accuracy"
+	"wallet-service/internal/apikey"
 	"wallet-service/internal/cache"
+	"wallet-service/internal/catalogue"
 	"wallet-service/internal/config"
 	"wallet-service/internal/currency_helpers"
+	"wallet-service/internal/jobs"
+	"wallet-service/internal/openapi"
+	"wallet-service/internal/predictor"
+	"wallet-service/internal/rates"
+	"wallet-service/internal/trading"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
@@ -22,92 +28,158 @@ type Service interface {
 	// routes
 	GetAvailableCurrencies(w http.ResponseWriter, r *http.Request)
 	ChangeCurrencyBanStatus(w http.ResponseWriter, r *http.Request)
+	ChangeCurrencyBanStatusBulk(w http.ResponseWriter, r *http.Request)
+	GetBanEvents(w http.ResponseWriter, r *http.Request)
+	GetCurrencyBanHistory(w http.ResponseWriter, r *http.Request)
+	GetCurrency(w http.ResponseWriter, r *http.Request)
+	AddCurrency(w http.ResponseWriter, r *http.Request)
+	DescribeCurrency(w http.ResponseWriter, r *http.Request)
+	EnableCurrency(w http.ResponseWriter, r *http.Request)
+	DisableCurrency(w http.ResponseWriter, r *http.Request)
 
 	GetCurrentCurrencyRate(w http.ResponseWriter, r *http.Request)
 	GetTimelineCurrencyRate(w http.ResponseWriter, r *http.Request)
+	GetPredictionAccuracy(w http.ResponseWriter, r *http.Request)
+	CreatePredictionJob(w http.ResponseWriter, r *http.Request)
+	GetPredictionJob(w http.ResponseWriter, r *http.Request)
+	CreateCurrencyQuote(w http.ResponseWriter, r *http.Request)
+
+	CreateWallet(w http.ResponseWriter, r *http.Request)
+	GetWallet(w http.ResponseWriter, r *http.Request)
+	OpenWalletAccount(w http.ResponseWriter, r *http.Request)
+	GetWalletBalances(w http.ResponseWriter, r *http.Request)
+
+	DepositToWallet(w http.ResponseWriter, r *http.Request)
+	WithdrawFromWallet(w http.ResponseWriter, r *http.Request)
+	TransferFromWallet(w http.ResponseWriter, r *http.Request)
+	GetWalletEntries(w http.ResponseWriter, r *http.Request)
+	CheckLedger(w http.ResponseWriter, r *http.Request)
+
+	ExchangeInWallet(w http.ResponseWriter, r *http.Request)
+
+	GetTradingRules(w http.ResponseWriter, r *http.Request)
+	GetTradingRule(w http.ResponseWriter, r *http.Request)
+	CreateTradingRule(w http.ResponseWriter, r *http.Request)
+	UpdateTradingRule(w http.ResponseWriter, r *http.Request)
+	DeleteTradingRule(w http.ResponseWriter, r *http.Request)
+
+	GetAPIKeys(w http.ResponseWriter, r *http.Request)
+	IssueAPIKey(w http.ResponseWriter, r *http.Request)
+	RevokeAPIKey(w http.ResponseWriter, r *http.Request)
+
+	GetOpenAPI(w http.ResponseWriter, r *http.Request)
 }
 
-func NewService(db *sqlx.DB, redisCache cache.Cache, cfg *config.Config) Service {
-	return &HttpService{
-		db:         db,
-		redisCache: redisCache,
-		cfg:        cfg,
-	}
+func NewService(
+	db *sqlx.DB,
+	redisCache cache.Cache,
+	rateProvider rates.RateProvider,
+	currencyCatalogue *catalogue.Catalogue,
+	tradingRules *trading.Engine,
+	apiKeys *apikey.Store,
+	predictors *predictor.Models,
+	predictionAccuracy *accuracy.Tracker,
+	predictionJobs *jobs.Queue,
+	spec *openapi.Document,
+	cfg *config.Config,
+) Service {
+	s := &HttpService{
+		db:             db,
+		redisCache:     redisCache,
+		rateProvider:   rateProvider,
+		catalogue:      currencyCatalogue,
+		tradingRules:   tradingRules,
+		apiKeys:        apiKeys,
+		accuracy:       predictionAccuracy,
+		predictionJobs: predictionJobs,
+		spec:           spec,
+		cfg:            cfg,
+	}
+
+	// a nil *predictor.Models would make a non-nil interface, predictions stay disabled without one
+	if predictors != nil {
+		s.predictors = predictors
+		predictionJobs.Start(s.runPredictionJob)
+	}
+
+	return s
+}
+
+// currencyCatalogue is the part of *catalogue.Catalogue the handlers use.
+type currencyCatalogue interface {
+	Get(code currency_helpers.CurrencyCode) (catalogue.Currency, bool)
+	Supported(code currency_helpers.CurrencyCode) bool
+	IsBanned(code currency_helpers.CurrencyCode) bool
+	MinorUnits(code currency_helpers.CurrencyCode) int32
+	Money(amount currency_helpers.Decimal, code currency_helpers.CurrencyCode) currency_helpers.Money
+	List() []catalogue.Currency
+	Add(ctx context.Context, info currency_helpers.CurrencyInfo) (*catalogue.Currency, error)
+	Describe(ctx context.Context, code currency_helpers.CurrencyCode, description catalogue.Description) (*catalogue.Currency, error)
+	SetEnabled(ctx context.Context, code currency_helpers.CurrencyCode, enabled bool) (*catalogue.Currency, error)
+	ValidateBanChange(change catalogue.BanChange) error
+	ChangeBans(ctx context.Context, changes []catalogue.BanChange) ([]catalogue.BanChangeResult, error)
+	NextBanChange(now time.Time) (time.Time, bool)
+	BanEvents(ctx context.Context, filter catalogue.BanEventFilter) ([]catalogue.BanEvent, error)
+}
+
+// tradingEngine is the part of *trading.Engine the handlers use.
+type tradingEngine interface {
+	CheckPair(from, to currency_helpers.CurrencyCode) error
+	CheckTrade(from, to currency_helpers.CurrencyCode, amount currency_helpers.Decimal) error
+	List() []trading.Rule
+	Get(id int64) (trading.Rule, bool)
+	Create(ctx context.Context, rule trading.Rule) (*trading.Rule, error)
+	Update(ctx context.Context, rule trading.Rule) (*trading.Rule, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+// predictionModels is the part of *predictor.Models the handlers use.
+type predictionModels interface {
+	Get(name string) (predictor.Predictor, bool)
+}
+
+// predictionQueue is the part of *jobs.Queue the handlers use.
+type predictionQueue interface {
+	Enqueue(ctx context.Context, base, second currency_helpers.CurrencyCode, model string) (*jobs.Job, error)
+	Find(ctx context.Context, base, second currency_helpers.CurrencyCode, model string) (*jobs.Job, error)
+	Get(ctx context.Context, id int64) (*jobs.Job, error)
 }
 
 type HttpService struct {
-	db         *sqlx.DB
-	redisCache cache.Cache
-	cfg        *config.Config
+	db             *sqlx.DB
+	redisCache     cache.Cache
+	rateProvider   rates.RateProvider
+	catalogue      currencyCatalogue
+	tradingRules   tradingEngine
+	apiKeys        *apikey.Store
+	predictors     predictionModels
+	accuracy       *accuracy.Tracker
+	predictionJobs predictionQueue
+	spec           *openapi.Document
+	cfg            *config.Config
 }
 
 func (s *HttpService) GetAvailableCurrencies(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	currencies := make([]currency_helpers.CurrencyCode, 0, len(currency_helpers.CodeToCurrency))
-	for curr := range currency_helpers.CodeToCurrency {
-		currencies = append(currencies, curr)
-	}
 
 	cacheCtx, cancel := context.WithTimeout(ctx, s.cfg.CacheTimeout)
 	defer cancel()
 	availableCurrencies, err := s.redisCache.GetAvailableCurrencies(cacheCtx)
 	if err != nil {
-		err = errors.Wrap(err, "error in get available currencies")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, cacheError(err, "error in get available currencies"))
 		return
 	}
 
 	if availableCurrencies != nil {
-		w.Header().Set("Content-Type", "application/json")
-		err = json.NewEncoder(w).Encode(availableCurrencies)
-		if err != nil {
-			err = errors.Wrap(err, "error in marshalling currencies")
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		w.WriteHeader(http.StatusOK)
-		return
-	}
-
-	queryBase := `
-		select cb.currency, cb.banned
-		from currency_bans as cb
-		where currency in (?);
-	`
-	query, params, err := sqlx.In(queryBase, currencies)
-	if err != nil {
-		err = errors.Wrap(err, "error in prepare query")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSON(w, r, http.StatusOK, availableCurrencies)
 		return
 	}
-	query = s.db.Rebind(query)
 
-	var curr2ban []currency_helpers.CurrencyWithBanStatus
-
-	dbCtx, cancel := context.WithTimeout(ctx, s.cfg.DBTimeout)
-	defer cancel()
-	err = s.db.SelectContext(dbCtx, &curr2ban, query, params...)
-	if err != nil {
-		err = errors.Wrap(err, "error in getting currency to ban data")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	cur2banMap := make(map[currency_helpers.CurrencyCode]currency_helpers.CurrencyWithBanStatus, len(curr2ban))
-	for _, curr := range curr2ban {
-		cur2banMap[curr.Currency] = curr
-	}
-
-	result := make([]currency_helpers.CurrencyWithBanStatus, 0, len(curr2ban))
-	for curr, _ := range currency_helpers.CodeToCurrency {
-		if v, ok := cur2banMap[curr]; ok {
-			result = append(result, v)
-		} else {
-			result = append(result, currency_helpers.CurrencyWithBanStatus{
-				Currency: curr,
-				Banned:   false,
-			})
+	currencies := s.catalogue.List()
+	result := make([]currency_helpers.CurrencyWithBanStatus, 0, len(currencies))
+	for _, currency := range currencies {
+		if currency.Enabled {
+			result = append(result, currency.WithBanStatus())
 		}
 	}
 
@@ -119,185 +191,140 @@ func (s *HttpService) GetAvailableCurrencies(w http.ResponseWriter, r *http.Requ
 		return si.Currency <= sj.Currency
 	})
 
-	cacheCtx, cancel = context.WithTimeout(ctx, s.cfg.CacheTimeout)
-	defer cancel()
-	err = s.redisCache.SetAvailableCurrencies(cacheCtx, result)
-	if err != nil {
-		log.Printf("error in save available currencies: %s", err.Error())
+	// the list must not outlive the next ban window opening or closing
+	var expiration time.Duration
+	next, hasNext := s.catalogue.NextBanChange(time.Now())
+	if hasNext {
+		expiration = time.Until(next)
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(result)
-	if err != nil {
-		err = errors.Wrap(err, "error in marshalling currencies")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	if !hasNext || expiration > 0 {
+		cacheCtx, cancel = context.WithTimeout(ctx, s.cfg.CacheTimeout)
+		defer cancel()
+		err = s.redisCache.SetAvailableCurrencies(cacheCtx, result, expiration)
+		if err != nil {
+			log.Printf("error in save available currencies: %s", err.Error())
+		}
 	}
 
-	w.WriteHeader(http.StatusOK)
-	return
+	writeJSON(w, r, http.StatusOK, result)
 }
 
+// ChangeCurrencyBanStatus opens a ban window for the currency or lifts its active and scheduled bans.
+// A ban without start begins immediately, a ban without end lasts until it is lifted.
 func (s *HttpService) ChangeCurrencyBanStatus(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	req := struct {
-		Currency currency_helpers.CurrencyCode `json:"currency"`
-		Banned   bool                          `json:"banned"`
-	}{}
+	req := catalogue.BanChange{}
 	defer r.Body.Close()
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		err = errors.Wrap(err, "error in unmarshalling request")
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, invalidBody(err))
 		return
 	}
 
-	if _, ok := currency_helpers.CodeToCurrency[req.Currency]; !ok {
-		err = errors.New("invalid currency")
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if _, ok := s.catalogue.Get(req.Currency); !ok {
+		writeError(w, r, invalidCurrency("invalid currency"))
 		return
 	}
+	req.Author = banAuthor(ctx)
 
-	query := `
-		insert into currency_bans (currency, banned) values ($1, $2) 
-		on conflict (currency)
-		do update set banned = $2 where excluded.currency = $1;
-	`
 	dbCtx, cancel := context.WithTimeout(ctx, s.cfg.DBTimeout)
 	defer cancel()
-	_, err = s.db.ExecContext(dbCtx, query, req.Currency, req.Banned)
+	results, err := s.catalogue.ChangeBans(dbCtx, []catalogue.BanChange{req})
 	if err != nil {
-		err = errors.Wrap(err, "error in update currency status")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	cacheCtx, cancel := context.WithTimeout(ctx, s.cfg.CacheTimeout)
-	defer cancel()
-	err = s.redisCache.CleanCacheForAvailableCurrencies(cacheCtx)
-	if err != nil {
-		log.Printf("error in clean available currencies: %s", err.Error())
+	var result interface{} = results[0].Lifted
+	if req.Banned {
+		result = results[0].Window
 	}
 
-	w.WriteHeader(http.StatusOK)
+	s.cleanAvailableCurrencies(ctx)
+
+	writeJSON(w, r, http.StatusOK, result)
 }
 
 func (s *HttpService) GetCurrentCurrencyRate(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	currencyCodeBase := currency_helpers.CurrencyCode(r.URL.Query().Get("base"))
-	if _, ok := currency_helpers.CodeToCurrency[currencyCodeBase]; !ok {
-		err := errors.New("invalid base currency code")
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !s.catalogue.Supported(currencyCodeBase) {
+		writeError(w, r, invalidCurrency("invalid base currency code"))
 		return
 	}
 
 	currencyCodeSecond := currency_helpers.CurrencyCode(r.URL.Query().Get("second"))
-	if _, ok := currency_helpers.CodeToCurrency[currencyCodeSecond]; !ok {
-		err := errors.New("invalid second currency code")
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !s.catalogue.Supported(currencyCodeSecond) {
+		writeError(w, r, invalidCurrency("invalid second currency code"))
 		return
 	}
 
-	cacheCtx, cancel := context.WithTimeout(ctx, s.cfg.CacheTimeout)
-	defer cancel()
-	currencyRate, err := s.redisCache.GetCurrencyLastRate(cacheCtx, currencyCodeBase, currencyCodeSecond)
+	err := s.tradingRules.CheckPair(currencyCodeBase, currencyCodeSecond)
 	if err != nil {
-		err = errors.Wrap(err, "error in get currency rate")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	if currencyRate != nil {
-		rYear, rMonth, rDay := currencyRate.Date.Date()
-		nYear, nMonth, nDay := time.Now().Date()
-		if rYear < nYear ||
-			rYear == nYear && rMonth < nMonth ||
-			rYear == nYear && rMonth == nMonth && rDay == nDay {
-			w.Header().Set("Content-Type", "application/json")
-			err = json.NewEncoder(w).Encode(currencyRate)
-			if err != nil {
-				err = errors.Wrap(err, "error in marshalling result")
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-	}
-
-	cbrCtx, cancel := context.WithTimeout(ctx, s.cfg.ExchangerAPITimeout)
-	defer cancel()
-
-	todayY, todayM, todayD := time.Now().Year(), time.Now().Month(), time.Now().Day()
-	previousDay := time.Date(todayY, todayM, todayD-1, 0, 0, 0, 0, time.UTC)
-	req, err := http.NewRequestWithContext(
-		cbrCtx,
-		http.MethodGet,
-		fmt.Sprintf(
-			"%s/%s?base=%s&places=4",
-			s.cfg.ExchangerAPIURL,
-			previousDay.Format("02.01.2006"),
-			currencyCodeBase,
-		),
-		nil,
-	)
+	currencyRate, err := s.currentRate(ctx, currencyCodeBase, currencyCodeSecond)
 	if err != nil {
-		err = errors.Wrap(err, "error in prepare request")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	client := http.DefaultClient
+	writeJSON(w, r, http.StatusOK, currencyRate)
+}
 
-	exchangerResp, err := client.Do(req)
+// currentRate returns the last known rate of the pair, refreshing it from the rate provider
+// once the cached one expires at the next publication. The date of the cached rate is not
+// checked: on weekends and holidays the provider answers with the rates of the last business day.
+func (s *HttpService) currentRate(
+	ctx context.Context,
+	currencyCodeBase currency_helpers.CurrencyCode,
+	currencyCodeSecond currency_helpers.CurrencyCode,
+) (*currency_helpers.CurrencyRate, error) {
+	cacheCtx, cancel := context.WithTimeout(ctx, s.cfg.CacheTimeout)
+	defer cancel()
+	currencyRate, err := s.redisCache.GetCurrencyLastRate(cacheCtx, currencyCodeBase, currencyCodeSecond)
 	if err != nil {
-		err = errors.Wrap(err, "error in get new data")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		return nil, cacheError(err, "error in get currency rate")
 	}
 
-	defer exchangerResp.Body.Close()
-
-	updatedCurrencyRates := &currency_helpers.CurrencyRatesResponse{}
-	err = json.NewDecoder(exchangerResp.Body).Decode(updatedCurrencyRates)
-	if err != nil {
-		err = errors.Wrap(err, "internal error in read JSON data")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	if currencyRate != nil && !currencyRate.Rate.IsZero() {
+		return currencyRate, nil
 	}
 
-	if !updatedCurrencyRates.Success {
-		err = errors.New("unsuccessful getting new rates")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	now := time.Now()
+	updatedCurrencyRates, err := s.rateProvider.ForDate(ctx, currencyCodeBase, latestPublication(now))
+	if err != nil {
+		return nil, providerError(err, "error in get new data")
 	}
 
 	_, ok := updatedCurrencyRates.Rates[currencyCodeSecond]
 	if !ok {
-		err = errors.Errorf("cannot find rate for '%s'", currencyCodeSecond.String())
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+		return nil, errors.Wrapf(errRateNotFound, "cannot find rate for '%s'", currencyCodeSecond.String())
 	}
 
 	cacheCtx, cancel = context.WithTimeout(ctx, s.cfg.CacheTimeout)
 	defer cancel()
-	err = s.redisCache.SetCurrencyLastRate(ctx, updatedCurrencyRates.CurrencyRates)
+	err = s.redisCache.SetCurrencyLastRate(cacheCtx, updatedCurrencyRates, nextPublication(now))
 	if err != nil {
 		log.Printf("error in save new rate: %s", err.Error())
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	ratesJson, err := json.Marshal(updatedCurrencyRates.CurrencyRates.ToResultRate(currencyCodeSecond))
-	if err != nil {
-		err = errors.New("marshal result error")
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
+	return updatedCurrencyRates.ToResultRate(currencyCodeSecond), nil
+}
+
+// latestPublication is the date of the latest rates expected from the provider, rates are published for the previous day.
+func latestPublication(now time.Time) time.Time {
+	year, month, day := now.UTC().Date()
+	return time.Date(year, month, day-1, 0, 0, 0, 0, time.UTC)
+}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write(ratesJson)
+// nextPublication is when the rates of today are expected from the provider.
+func nextPublication(now time.Time) time.Time {
+	return latestPublication(now).AddDate(0, 0, 2)
 }
 
 func (s *HttpService) GetTimelineCurrencyRate(w http.ResponseWriter, r *http.Request) {
@@ -305,16 +332,20 @@ func (s *HttpService) GetTimelineCurrencyRate(w http.ResponseWriter, r *http.Req
 
 	var err error
 	currencyCodeBase := currency_helpers.CurrencyCode(r.URL.Query().Get("base"))
-	if _, ok := currency_helpers.CodeToCurrency[currencyCodeBase]; !ok {
-		err = errors.New("invalid base currency code")
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !s.catalogue.Supported(currencyCodeBase) {
+		writeError(w, r, invalidCurrency("invalid base currency code"))
 		return
 	}
 
 	currencyCodeSecond := currency_helpers.CurrencyCode(r.URL.Query().Get("second"))
-	if _, ok := currency_helpers.CodeToCurrency[currencyCodeSecond]; !ok {
-		err = errors.New("invalid second currency code")
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !s.catalogue.Supported(currencyCodeSecond) {
+		writeError(w, r, invalidCurrency("invalid second currency code"))
+		return
+	}
+
+	err = s.tradingRules.CheckPair(currencyCodeBase, currencyCodeSecond)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
@@ -325,159 +356,105 @@ func (s *HttpService) GetTimelineCurrencyRate(w http.ResponseWriter, r *http.Req
 
 	startDateStr := r.URL.Query().Get("start")
 	if startDate, err = time.Parse(currency_helpers.CustomTimeLayout, startDateStr); err != nil {
-		err = errors.New("invalid start period date")
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, badRequest("invalid start period date"))
 		return
 	}
 
 	endDateStr := r.URL.Query().Get("end")
 	if endDate, err = time.Parse(currency_helpers.CustomTimeLayout, endDateStr); err != nil {
-		err = errors.New("invalid end period date")
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, badRequest("invalid end period date"))
 		return
 	}
 
-	cacheCtx, cancel := context.WithTimeout(ctx, s.cfg.CacheTimeout)
-	defer cancel()
-	currencyRate, err := s.redisCache.GetTimestampRate(ctx, currencyCodeBase, currencyCodeSecond)
-	if err != nil || currencyRate == nil {
-		if err != nil {
-			err = errors.Wrap(err, "error in get timestamp rate from cache")
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		cbrCtx, cancel := context.WithTimeout(ctx, s.cfg.ExchangerAPITimeout)
-		defer cancel()
-
-		todayY, todayM, todayD := time.Now().Year(), time.Now().Month(), time.Now().Day()
-		previousDay := time.Date(todayY, todayM, todayD-1, 0, 0, 0, 0, time.UTC)
-		previousYear := time.Date(todayY-1, todayM, todayD, 0, 0, 0, 0, time.UTC)
-		// какая-то странная бага, не работает today
-		url := fmt.Sprintf(
-			"%s/timeseries?start_date=%s&end_date=%s&base=%s&symbols=%s&places=4",
-			s.cfg.ExchangerAPIURL,
-			previousYear.Format(currency_helpers.CustomTimeLayout),
-			previousDay.Format(currency_helpers.CustomTimeLayout),
-			currencyCodeBase,
-			currencyCodeSecond,
-		)
-
-		req, err := http.NewRequestWithContext(cbrCtx, http.MethodGet, url, nil)
-		if err != nil {
-			err = errors.Wrap(err, "error in prepare request")
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		client := http.DefaultClient
-
-		exchangerResp, err := client.Do(req)
-		if err != nil {
-			err = errors.Wrap(err, "error in get new data")
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		defer exchangerResp.Body.Close()
-
-		timelineCurrencyRates := &currency_helpers.CurrencyTimelineRatesResponse{}
-		err = json.NewDecoder(exchangerResp.Body).Decode(timelineCurrencyRates)
-		if err != nil {
-			err = errors.Wrap(err, "internal error in read JSON data")
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		if !timelineCurrencyRates.Success {
-			err = errors.New("unsuccessful getting new rates")
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		currencyRate = timelineCurrencyRates.ToResultTimelineRates(currencyCodeSecond)
-
-		predictorCtx, cancel := context.WithTimeout(ctx, time.Minute*10)
-		defer cancel()
-
-		dataForPredictions, err := json.Marshal(currencyRate.Rates)
-		if err != nil {
-			err = errors.Wrap(err, "error in prepare data for predictions")
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		req, err = http.NewRequestWithContext(
-			predictorCtx,
-			http.MethodPost,
-			fmt.Sprintf(
-				"https://stbuddy.xyz/predict",
-			),
-			bytes.NewBuffer(dataForPredictions),
-		)
-		req.Header.Set("Content-Type", "application/json")
-		if err != nil {
-			err = errors.Wrap(err, "error in prepare request")
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		predictorResp, err := client.Do(req)
-		if err != nil {
-			err = errors.Wrap(err, "error in get predictions")
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+	var ratePredictor predictor.Predictor
+	if s.predictors != nil {
+		model := r.URL.Query().Get("model")
+		var ok bool
+		ratePredictor, ok = s.predictors.Get(model)
+		if !ok {
+			writeError(w, r, badRequest(fmt.Sprintf("unknown prediction model '%s'", model)))
 			return
 		}
+	}
 
-		defer predictorResp.Body.Close()
-		var predictedRates []float64
-		b, _ := io.ReadAll(predictorResp.Body)
-		err = json.NewDecoder(bytes.NewBuffer(b)).Decode(&predictedRates)
-		if err != nil {
-			err = errors.Wrap(err, "error in reading predictor response")
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		predictions := make(map[currency_helpers.CustomTime]float64, len(predictedRates))
-
-		i := 0
-		for _, rate := range predictedRates {
-			t := time.Date(todayY, todayM, todayD+i, 0, 0, 0, 0, time.UTC)
-			i += 1
-			predictions[currency_helpers.CustomTime{Time: t}] = rate
-		}
-		currencyRate.Predictions = predictions
+	currencyRate, err := s.timelineRates(ctx, currencyCodeBase, currencyCodeSecond)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-		cacheCtx, cancel = context.WithTimeout(ctx, s.cfg.CacheTimeout)
-		defer cancel()
-		err = s.redisCache.SaveTimestampRate(cacheCtx, currencyRate)
-		if err != nil {
-			log.Printf("error in save timestamp rate: %s", err.Error())
-		}
+	var (
+		forecast      *currency_helpers.Forecast
+		predictionJob *jobs.Job
+	)
+	if ratePredictor != nil {
+		forecast, predictionJob = s.forecast(ctx, ratePredictor, currencyCodeBase, currencyCodeSecond)
 	}
 
-	timelineRates := make(map[currency_helpers.CustomTime]float64)
+	timelineRates := make(map[currency_helpers.CustomTime]currency_helpers.Decimal)
 	for t, rate := range currencyRate.Rates {
 		if t.Equal(startDate) || t.After(startDate) && t.Before(endDate) {
 			timelineRates[t] = rate
 		}
 	}
-	result := currency_helpers.CurrencyTimelineRate{
-		Base:        currencyRate.Base,
-		Second:      currencyRate.Second,
-		Rates:       timelineRates,
-		Predictions: currencyRate.Predictions,
-		StartDate:   currency_helpers.CustomTime{Time: startDate},
-		EndDate:     currency_helpers.CustomTime{Time: endDate},
+	result := timelineResponse{
+		CurrencyTimelineRate: currency_helpers.CurrencyTimelineRate{
+			Base:        currencyRate.Base,
+			Second:      currencyRate.Second,
+			Rates:       timelineRates,
+			Predictions: forecast,
+			StartDate:   currency_helpers.CustomTime{Time: startDate},
+			EndDate:     currency_helpers.CustomTime{Time: endDate},
+			Source:      currencyRate.Source,
+		},
+		PredictionJob: predictionJob,
+	}
+
+	// passed by pointer, so the dates are marshalled by CustomTime
+	writeJSON(w, r, http.StatusOK, &result)
+}
+
+// timelineRates returns the rates of the pair for the year up to yesterday,
+// which the time series are cut from and the predictions are made on.
+func (s *HttpService) timelineRates(
+	ctx context.Context,
+	currencyCodeBase currency_helpers.CurrencyCode,
+	currencyCodeSecond currency_helpers.CurrencyCode,
+) (*currency_helpers.CurrencyTimelineRate, error) {
+	cacheCtx, cancel := context.WithTimeout(ctx, s.cfg.CacheTimeout)
+	defer cancel()
+	currencyRate, err := s.redisCache.GetTimestampRate(cacheCtx, currencyCodeBase, currencyCodeSecond)
+	if err != nil {
+		return nil, cacheError(err, "error in get timestamp rate from cache")
+	}
+	if currencyRate != nil {
+		return currencyRate, nil
+	}
+
+	todayY, todayM, todayD := time.Now().Year(), time.Now().Month(), time.Now().Day()
+	previousDay := time.Date(todayY, todayM, todayD-1, 0, 0, 0, 0, time.UTC)
+	previousYear := time.Date(todayY-1, todayM, todayD, 0, 0, 0, 0, time.UTC)
+	// какая-то странная бага, не работает today
+	timelineCurrencyRates, err := s.rateProvider.TimeSeries(
+		ctx,
+		currencyCodeBase,
+		currencyCodeSecond,
+		previousYear,
+		previousDay,
+	)
+	if err != nil {
+		return nil, providerError(err, "error in get new data")
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(result)
+	currencyRate = timelineCurrencyRates.ToResultTimelineRates(currencyCodeSecond)
+
+	cacheCtx, cancel = context.WithTimeout(ctx, s.cfg.CacheTimeout)
+	defer cancel()
+	// refetched once the rates of today are published, so predictions train on the latest history
+	err = s.redisCache.SaveTimestampRate(cacheCtx, currencyRate, nextPublication(time.Now()))
 	if err != nil {
-		err = errors.Wrap(err, "error in prepare response date")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		log.Printf("error in save timestamp rate: %s", err.Error())
 	}
 
-	w.WriteHeader(http.StatusOK)
+	return currencyRate, nil
 }
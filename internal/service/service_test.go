@@ -0,0 +1,353 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"wallet-service/internal/cache"
+	"wallet-service/internal/catalogue"
+	"wallet-service/internal/config"
+	"wallet-service/internal/currency_helpers"
+	"wallet-service/internal/rates"
+	"wallet-service/internal/trading"
+
+	"github.com/pkg/errors"
+)
+
+// fakeCache serves the last rate and the time series and records the saved rates,
+// the embedded interface is left nil so any other call fails the test with a panic.
+type fakeCache struct {
+	cache.Cache
+	rate     *currency_helpers.CurrencyRate
+	err      error
+	saved    *currency_helpers.CurrencyRates
+	expires  time.Time
+	timeline *currency_helpers.CurrencyTimelineRate
+	counts   map[string]int64
+	quote    *currency_helpers.Quote
+}
+
+func (c *fakeCache) GetCurrencyLastRate(
+	_ context.Context,
+	_ currency_helpers.CurrencyCode,
+	_ currency_helpers.CurrencyCode,
+) (*currency_helpers.CurrencyRate, error) {
+	return c.rate, c.err
+}
+
+func (c *fakeCache) SetCurrencyLastRate(
+	_ context.Context,
+	currencyRates *currency_helpers.CurrencyRates,
+	expiresAt time.Time,
+) error {
+	c.saved, c.expires = currencyRates, expiresAt
+	return nil
+}
+
+func (c *fakeCache) GetTimestampRate(
+	_ context.Context,
+	_ currency_helpers.CurrencyCode,
+	_ currency_helpers.CurrencyCode,
+) (*currency_helpers.CurrencyTimelineRate, error) {
+	return c.timeline, nil
+}
+
+// GetForecast misses, forecasts are only cached by jobs that succeeded.
+func (c *fakeCache) GetForecast(
+	_ context.Context,
+	_ currency_helpers.CurrencyCode,
+	_ currency_helpers.CurrencyCode,
+	_ string,
+) (*currency_helpers.Forecast, error) {
+	return nil, nil
+}
+
+func (c *fakeCache) SaveQuote(_ context.Context, quote *currency_helpers.Quote) error {
+	c.quote = quote
+	return nil
+}
+
+func (c *fakeCache) GetQuote(_ context.Context, id string) (*currency_helpers.Quote, error) {
+	if c.quote == nil || c.quote.ID != id {
+		return nil, nil
+	}
+	return c.quote, nil
+}
+
+func (c *fakeCache) CountRequest(_ context.Context, key string, _ time.Time) (int64, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	if c.counts == nil {
+		c.counts = make(map[string]int64)
+	}
+	c.counts[key]++
+	return c.counts[key], nil
+}
+
+// fakeProvider returns fixed rates for any date and records the requested one.
+type fakeProvider struct {
+	rates.RateProvider
+	rates     *currency_helpers.CurrencyRates
+	err       error
+	requested time.Time
+}
+
+func (p *fakeProvider) ForDate(
+	_ context.Context,
+	_ currency_helpers.CurrencyCode,
+	date time.Time,
+) (*currency_helpers.CurrencyRates, error) {
+	p.requested = date
+	return p.rates, p.err
+}
+
+// fakeCatalogue looks up fixed currencies, the embedded interface is left nil
+// so any other call fails the test with a panic.
+type fakeCatalogue struct {
+	currencyCatalogue
+	currencies map[currency_helpers.CurrencyCode]catalogue.Currency
+}
+
+func (c *fakeCatalogue) Get(code currency_helpers.CurrencyCode) (catalogue.Currency, bool) {
+	currency, ok := c.currencies[code]
+	return currency, ok
+}
+
+func (c *fakeCatalogue) Supported(code currency_helpers.CurrencyCode) bool {
+	currency, ok := c.Get(code)
+	return ok && currency.Enabled
+}
+
+func (c *fakeCatalogue) IsBanned(code currency_helpers.CurrencyCode) bool {
+	currency, ok := c.Get(code)
+	return ok && currency.Banned
+}
+
+func (c *fakeCatalogue) Money(amount currency_helpers.Decimal, code currency_helpers.CurrencyCode) currency_helpers.Money {
+	currency, _ := c.Get(code)
+	return currency_helpers.NewMoney(amount, code, currency.MinorUnits)
+}
+
+func testCatalogue() *fakeCatalogue {
+	c := &fakeCatalogue{currencies: make(map[currency_helpers.CurrencyCode]catalogue.Currency)}
+	for code, enabled := range map[currency_helpers.CurrencyCode]bool{"USD": true, "EUR": true, "RUB": true, "GBP": false} {
+		c.currencies[code] = catalogue.Currency{
+			CurrencyInfo: currency_helpers.CurrencyInfo{Code: code, MinorUnits: 2},
+			Enabled:      enabled,
+		}
+	}
+	return c
+}
+
+// fakeTradingRules evaluates fixed rules the way the engine does.
+type fakeTradingRules struct {
+	tradingEngine
+	rules []trading.Rule
+}
+
+func (e *fakeTradingRules) CheckPair(from, to currency_helpers.CurrencyCode) error {
+	return trading.Evaluate(e.rules, from, to, nil)
+}
+
+func (e *fakeTradingRules) CheckTrade(from, to currency_helpers.CurrencyCode, amount currency_helpers.Decimal) error {
+	return trading.Evaluate(e.rules, from, to, &amount)
+}
+
+func decimal(t *testing.T, s string) currency_helpers.Decimal {
+	t.Helper()
+	d, err := currency_helpers.ParseDecimal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestLatestPublication(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		now        time.Time
+		want, next time.Time
+	}{
+		{"midday", time.Date(2023, 5, 17, 12, 0, 0, 0, time.UTC), date(2023, 5, 16), date(2023, 5, 18)},
+		{"first of month", time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), date(2023, 4, 30), date(2023, 5, 2)},
+		{"first of year", time.Date(2023, 1, 1, 8, 0, 0, 0, time.UTC), date(2022, 12, 31), date(2023, 1, 2)},
+		{"ahead of UTC", time.Date(2023, 5, 17, 1, 0, 0, 0, moscow), date(2023, 5, 15), date(2023, 5, 17)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := latestPublication(tt.now); !got.Equal(tt.want) {
+				t.Errorf("got latest %s, want %s", got, tt.want)
+			}
+			if got := nextPublication(tt.now); !got.Equal(tt.next) {
+				t.Errorf("got next %s, want %s", got, tt.next)
+			}
+		})
+	}
+}
+
+func TestGetCurrentCurrencyRate(t *testing.T) {
+	published := latestPublication(time.Now())
+	cachedRate := func(date time.Time) *currency_helpers.CurrencyRate {
+		return &currency_helpers.CurrencyRate{
+			Base:   "USD",
+			Second: "EUR",
+			Rate:   decimal(t, "0.91"),
+			Date:   currency_helpers.CustomTime{Time: date},
+			Source: "cache",
+		}
+	}
+	providerRates := &currency_helpers.CurrencyRates{
+		Base:   "USD",
+		Rates:  map[currency_helpers.CurrencyCode]currency_helpers.Decimal{"EUR": decimal(t, "0.92")},
+		Date:   currency_helpers.CustomTime{Time: published},
+		Source: "provider",
+	}
+	rateBody := func(rate string, date time.Time, source string) string {
+		return fmt.Sprintf(`{"base":"USD","second":"EUR","rate":"%s","date":"%s","source":"%s"}`+"\n",
+			rate, date.Format(currency_helpers.CustomTimeLayout), source)
+	}
+	errorBody := func(code, message string) string {
+		return fmt.Sprintf(`{"code":"%s","message":"%s"}`+"\n", code, message)
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		cache     *fakeCache
+		provider  *fakeProvider
+		wantCode  int
+		wantBody  string
+		refetched bool
+	}{
+		{
+			name:     "unknown base",
+			query:    "base=XXX&second=EUR",
+			wantCode: http.StatusBadRequest,
+			wantBody: errorBody("invalid_currency", "invalid base currency code"),
+		},
+		{
+			name:     "disabled second",
+			query:    "base=USD&second=GBP",
+			wantCode: http.StatusBadRequest,
+			wantBody: errorBody("invalid_currency", "invalid second currency code"),
+		},
+		{
+			name:     "blocked pair",
+			query:    "base=USD&second=RUB",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: errorBody("pair_blocked", "USD to RUB: sanctions: exchange direction is blocked"),
+		},
+		{
+			name:     "cache failure",
+			query:    "base=USD&second=EUR",
+			cache:    &fakeCache{err: errors.New("connection refused")},
+			wantCode: http.StatusServiceUnavailable,
+			wantBody: errorBody("cache_failure", "cache is unavailable"),
+		},
+		{
+			name:     "fresh cached rate",
+			query:    "base=USD&second=EUR",
+			cache:    &fakeCache{rate: cachedRate(published)},
+			provider: &fakeProvider{},
+			wantCode: http.StatusOK,
+			wantBody: rateBody("0.91", published, "cache"),
+		},
+		{
+			// the cached rate expires at the next publication, until then an earlier business day is as fresh as it gets
+			name:     "rate of the last business day",
+			query:    "base=USD&second=EUR",
+			cache:    &fakeCache{rate: cachedRate(published.AddDate(0, 0, -2))},
+			provider: &fakeProvider{},
+			wantCode: http.StatusOK,
+			wantBody: rateBody("0.91", published.AddDate(0, 0, -2), "cache"),
+		},
+		{
+			name:      "nothing cached",
+			query:     "base=USD&second=EUR",
+			cache:     &fakeCache{},
+			provider:  &fakeProvider{rates: providerRates},
+			wantCode:  http.StatusOK,
+			wantBody:  rateBody("0.92", published, "provider"),
+			refetched: true,
+		},
+		{
+			name:      "provider failure",
+			query:     "base=USD&second=EUR",
+			cache:     &fakeCache{},
+			provider:  &fakeProvider{err: errors.New("timeout")},
+			wantCode:  http.StatusServiceUnavailable,
+			wantBody:  errorBody("provider_unavailable", "rate provider is unavailable"),
+			refetched: true,
+		},
+		{
+			name:  "rate missing from the provider",
+			query: "base=USD&second=EUR",
+			cache: &fakeCache{},
+			provider: &fakeProvider{rates: &currency_helpers.CurrencyRates{
+				Base:  "USD",
+				Rates: map[currency_helpers.CurrencyCode]currency_helpers.Decimal{},
+			}},
+			wantCode:  http.StatusUnprocessableEntity,
+			wantBody:  errorBody("rate_not_found", "cannot find rate for 'EUR': rate not found"),
+			refetched: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &HttpService{
+				redisCache:   tt.cache,
+				rateProvider: tt.provider,
+				catalogue:    testCatalogue(),
+				tradingRules: &fakeTradingRules{rules: []trading.Rule{{From: "USD", To: "RUB", Kind: trading.Block, Reason: "sanctions"}}},
+				cfg:          &config.Config{CacheTimeout: time.Second},
+			}
+			// a nil fake would be a non-nil interface, so unused dependencies are left unset
+			if tt.cache == nil {
+				s.redisCache = nil
+			}
+			if tt.provider == nil {
+				s.rateProvider = nil
+			}
+
+			w := httptest.NewRecorder()
+			s.GetCurrentCurrencyRate(w, httptest.NewRequest(http.MethodGet, "/currency/current-rate?"+tt.query, nil))
+
+			if w.Code != tt.wantCode {
+				t.Errorf("got status %d, want %d", w.Code, tt.wantCode)
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+				t.Errorf("got Content-Type %q", contentType)
+			}
+			if w.Body.String() != tt.wantBody {
+				t.Errorf("got body %q, want %q", w.Body.String(), tt.wantBody)
+			}
+
+			if tt.provider == nil {
+				return
+			}
+			if refetched := !tt.provider.requested.IsZero(); refetched != tt.refetched {
+				t.Fatalf("got refetched %v, want %v", refetched, tt.refetched)
+			}
+			if tt.refetched {
+				if !tt.provider.requested.Equal(published) {
+					t.Errorf("requested rates of %s, want %s", tt.provider.requested, published)
+				}
+				if saved := tt.cache.saved != nil; saved != (tt.wantCode == http.StatusOK) {
+					t.Errorf("got refetched rates cached %v with status %d", saved, tt.wantCode)
+				}
+				if next := nextPublication(time.Now()); tt.cache.saved != nil && !tt.cache.expires.Equal(next) {
+					t.Errorf("got refetched rates cached until %s, want %s", tt.cache.expires, next)
+				}
+			}
+		})
+	}
+}
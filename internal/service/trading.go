@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"wallet-service/internal/trading"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+)
+
+func (s *HttpService) GetTradingRules(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, http.StatusOK, s.tradingRules.List())
+}
+
+func (s *HttpService) GetTradingRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, badRequest("invalid trading rule id"))
+		return
+	}
+
+	rule, ok := s.tradingRules.Get(id)
+	if !ok {
+		writeError(w, r, errors.Wrapf(trading.ErrRuleNotFound, "%d", id))
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, rule)
+}
+
+func (s *HttpService) CreateTradingRule(w http.ResponseWriter, r *http.Request) {
+	rule, ok := s.decodeTradingRule(w, r)
+	if !ok {
+		return
+	}
+
+	dbCtx, cancel := context.WithTimeout(r.Context(), s.cfg.DBTimeout)
+	defer cancel()
+	result, err := s.tradingRules.Create(dbCtx, rule)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusCreated, result)
+}
+
+func (s *HttpService) UpdateTradingRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, badRequest("invalid trading rule id"))
+		return
+	}
+
+	rule, ok := s.decodeTradingRule(w, r)
+	if !ok {
+		return
+	}
+	rule.ID = id
+
+	dbCtx, cancel := context.WithTimeout(r.Context(), s.cfg.DBTimeout)
+	defer cancel()
+	result, err := s.tradingRules.Update(dbCtx, rule)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, result)
+}
+
+func (s *HttpService) DeleteTradingRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, badRequest("invalid trading rule id"))
+		return
+	}
+
+	dbCtx, cancel := context.WithTimeout(r.Context(), s.cfg.DBTimeout)
+	defer cancel()
+	err = s.tradingRules.Delete(dbCtx, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeNoContent(w)
+}
+
+func (s *HttpService) decodeTradingRule(w http.ResponseWriter, r *http.Request) (trading.Rule, bool) {
+	rule := trading.Rule{}
+	defer r.Body.Close()
+	err := json.NewDecoder(r.Body).Decode(&rule)
+	if err != nil {
+		writeError(w, r, invalidBody(err))
+		return rule, false
+	}
+
+	if rule.From.IsSet() {
+		if _, ok := s.catalogue.Get(rule.From); !ok {
+			writeError(w, r, invalidCurrency("invalid from currency"))
+			return rule, false
+		}
+	}
+	if rule.To.IsSet() {
+		if _, ok := s.catalogue.Get(rule.To); !ok {
+			writeError(w, r, invalidCurrency("invalid to currency"))
+			return rule, false
+		}
+	}
+
+	return rule, true
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"wallet-service/internal/apierror"
+	"wallet-service/internal/auth"
+	"wallet-service/internal/currency_helpers"
+	"wallet-service/internal/wallet"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+)
+
+func (s *HttpService) CreateWallet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := struct {
+		UserID string `json:"userId"`
+	}{}
+	defer r.Body.Close()
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, r, invalidBody(err))
+		return
+	}
+
+	// users create wallets for themselves, admins may create them for anyone
+	principal, _ := auth.FromContext(ctx)
+	if req.UserID == "" {
+		req.UserID = principal.Subject
+	}
+	if req.UserID != principal.Subject && !principal.IsAdmin() {
+		writeError(w, r, apierror.New(
+			http.StatusForbidden, apierror.CodeForbidden, "cannot create a wallet for another user",
+		))
+		return
+	}
+
+	query := `
+		insert into wallets (user_id) values ($1)
+		returning id, user_id, created_at;
+	`
+	dbCtx, cancel := context.WithTimeout(ctx, s.cfg.DBTimeout)
+	defer cancel()
+	var result wallet.Wallet
+	err = s.db.GetContext(dbCtx, &result, query, req.UserID)
+	if err != nil {
+		writeError(w, r, errors.Wrap(err, "error in create wallet"))
+		return
+	}
+	result.Accounts = []wallet.Account{}
+
+	writeJSON(w, r, http.StatusCreated, result)
+}
+
+func (s *HttpService) GetWallet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	walletID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, badRequest("invalid wallet id"))
+		return
+	}
+
+	result, err := s.getWallet(ctx, walletID)
+	if err != nil {
+		writeError(w, r, walletError(err, walletID))
+		return
+	}
+
+	query := `
+		select wa.id, wa.wallet_id, wa.currency, wa.balance, wa.created_at
+		from wallet_accounts as wa
+		where wa.wallet_id = $1
+		order by wa.currency;
+	`
+	dbCtx, cancel := context.WithTimeout(ctx, s.cfg.DBTimeout)
+	defer cancel()
+	result.Accounts = []wallet.Account{}
+	err = s.db.SelectContext(dbCtx, &result.Accounts, query, walletID)
+	if err != nil {
+		writeError(w, r, errors.Wrap(err, "error in get wallet accounts"))
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, result)
+}
+
+func (s *HttpService) OpenWalletAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	walletID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, badRequest("invalid wallet id"))
+		return
+	}
+
+	req := struct {
+		Currency currency_helpers.CurrencyCode `json:"currency"`
+	}{}
+	defer r.Body.Close()
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, r, invalidBody(err))
+		return
+	}
+
+	if !s.catalogue.Supported(req.Currency) {
+		writeError(w, r, invalidCurrency("invalid currency"))
+		return
+	}
+
+	if s.catalogue.IsBanned(req.Currency) {
+		writeError(w, r, apierror.Errorf(
+			http.StatusUnprocessableEntity, apierror.CodeCurrencyBanned, "currency '%s' is banned", req.Currency,
+		))
+		return
+	}
+
+	_, err = s.getWallet(ctx, walletID)
+	if err != nil {
+		writeError(w, r, walletError(err, walletID))
+		return
+	}
+
+	query := `
+		insert into wallet_accounts (wallet_id, currency) values ($1, $2)
+		on conflict (wallet_id, currency) do nothing
+		returning id, wallet_id, currency, balance, created_at;
+	`
+	dbCtx, cancel := context.WithTimeout(ctx, s.cfg.DBTimeout)
+	defer cancel()
+	var result wallet.Account
+	err = s.db.GetContext(dbCtx, &result, query, walletID, req.Currency)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, r, apierror.Errorf(
+				http.StatusConflict, apierror.CodeAccountExists, "account in '%s' already opened", req.Currency,
+			))
+			return
+		}
+		writeError(w, r, errors.Wrap(err, "error in open wallet account"))
+		return
+	}
+
+	writeJSON(w, r, http.StatusCreated, result)
+}
+
+func (s *HttpService) GetWalletBalances(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	walletID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, badRequest("invalid wallet id"))
+		return
+	}
+
+	_, err = s.getWallet(ctx, walletID)
+	if err != nil {
+		writeError(w, r, walletError(err, walletID))
+		return
+	}
+
+	query := `
+		select wa.currency, wa.balance
+		from wallet_accounts as wa
+		where wa.wallet_id = $1
+		order by wa.currency;
+	`
+	dbCtx, cancel := context.WithTimeout(ctx, s.cfg.DBTimeout)
+	defer cancel()
+	result := []wallet.Balance{}
+	err = s.db.SelectContext(dbCtx, &result, query, walletID)
+	if err != nil {
+		writeError(w, r, errors.Wrap(err, "error in get wallet balances"))
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, result)
+}
+
+// getWallet returns the wallet if it belongs to the caller, admins may access any wallet.
+// Wallets of other users are reported as missing so that their ids are not disclosed.
+func (s *HttpService) getWallet(ctx context.Context, walletID int64) (*wallet.Wallet, error) {
+	result, err := s.findWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	principal, ok := auth.FromContext(ctx)
+	if !ok || !principal.IsAdmin() && principal.Subject != result.UserID {
+		return nil, sql.ErrNoRows
+	}
+
+	return result, nil
+}
+
+// walletError reports a wallet missing or belonging to another user as not found.
+func walletError(err error, walletID int64) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return apierror.Errorf(http.StatusNotFound, apierror.CodeWalletNotFound, "wallet %d not found", walletID)
+	}
+	return errors.Wrap(err, "error in get wallet")
+}
+
+// findWallet returns a user wallet regardless of its owner.
+func (s *HttpService) findWallet(ctx context.Context, walletID int64) (*wallet.Wallet, error) {
+	query := `
+		select w.id, w.user_id, w.created_at
+		from wallets as w
+		where w.id = $1 and not w.system;
+	`
+	dbCtx, cancel := context.WithTimeout(ctx, s.cfg.DBTimeout)
+	defer cancel()
+	var result wallet.Wallet
+	err := s.db.GetContext(dbCtx, &result, query, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,194 @@
+package trading
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+	"wallet-service/internal/config"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+const ruleColumns = `
+	id, coalesce(from_currency, '') as from_currency, coalesce(to_currency, '') as to_currency,
+	kind, max_amount, reason, created_at, updated_at
+`
+
+// Engine keeps trading rules in Postgres and evaluates them against an in-memory snapshot,
+// replaced after every change made through it and periodically reloaded.
+type Engine struct {
+	db *sqlx.DB
+
+	mu    sync.RWMutex
+	rules []Rule
+}
+
+func InitEngine(db *sqlx.DB, cfg *config.Config) (*Engine, error) {
+	e := &Engine{
+		db: db,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DBTimeout)
+	defer cancel()
+	err := e.Refresh(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "load trading rules")
+	}
+
+	if cfg.TradingRulesRefreshInterval > 0 {
+		go e.refreshLoop(cfg.TradingRulesRefreshInterval, cfg.DBTimeout)
+	}
+
+	return e, nil
+}
+
+func (e *Engine) refreshLoop(interval, timeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := e.Refresh(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("error in refresh trading rules: %s", err.Error())
+		}
+	}
+}
+
+func (e *Engine) Refresh(ctx context.Context) error {
+	rules := make([]Rule, 0)
+	err := e.db.SelectContext(ctx, &rules, "select "+ruleColumns+" from trading_rules order by id;")
+	if err != nil {
+		return errors.Wrap(err, "select trading rules")
+	}
+
+	e.mu.Lock()
+	e.rules = rules
+	e.mu.Unlock()
+
+	return nil
+}
+
+// CheckPair reports whether exchanges in the direction are blocked.
+func (e *Engine) CheckPair(from, to currency_helpers.CurrencyCode) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return Evaluate(e.rules, from, to, nil)
+}
+
+// CheckTrade checks the exchange of amount of from currency against all rules.
+func (e *Engine) CheckTrade(from, to currency_helpers.CurrencyCode, amount currency_helpers.Decimal) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return Evaluate(e.rules, from, to, &amount)
+}
+
+func (e *Engine) List() []Rule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return append([]Rule(nil), e.rules...)
+}
+
+func (e *Engine) Get(id int64) (Rule, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for _, rule := range e.rules {
+		if rule.ID == id {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+func (e *Engine) Create(ctx context.Context, rule Rule) (*Rule, error) {
+	err := rule.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		insert into trading_rules (from_currency, to_currency, kind, max_amount, reason)
+		values (nullif(:from_currency, ''), nullif(:to_currency, ''), :kind, :max_amount, :reason)
+		returning ` + ruleColumns + `;`
+	return e.write(ctx, query, rule)
+}
+
+func (e *Engine) Update(ctx context.Context, rule Rule) (*Rule, error) {
+	err := rule.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		update trading_rules
+		set from_currency = nullif(:from_currency, ''), to_currency = nullif(:to_currency, ''),
+			kind = :kind, max_amount = :max_amount, reason = :reason, updated_at = now()
+		where id = :id
+		returning ` + ruleColumns + `;`
+	return e.write(ctx, query, rule)
+}
+
+func (e *Engine) Delete(ctx context.Context, id int64) error {
+	result, err := e.db.ExecContext(ctx, "delete from trading_rules where id = $1;", id)
+	if err != nil {
+		return errors.Wrap(err, "delete trading rule")
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "delete trading rule")
+	}
+	if count == 0 {
+		return errors.Wrapf(ErrRuleNotFound, "%d", id)
+	}
+
+	e.mu.Lock()
+	rules := make([]Rule, 0, len(e.rules))
+	for _, rule := range e.rules {
+		if rule.ID != id {
+			rules = append(rules, rule)
+		}
+	}
+	e.rules = rules
+	e.mu.Unlock()
+
+	return nil
+}
+
+// write runs the insert or update query and puts the returned rule into the snapshot.
+func (e *Engine) write(ctx context.Context, query string, rule Rule) (*Rule, error) {
+	query, params, err := e.db.BindNamed(query, rule)
+	if err != nil {
+		return nil, errors.Wrap(err, "prepare trading rule query")
+	}
+
+	result := &Rule{}
+	err = e.db.QueryRowxContext(ctx, query, params...).StructScan(result)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.Wrapf(ErrRuleNotFound, "%d", rule.ID)
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return nil, errors.Wrap(ErrInvalidRule, "unknown currency")
+		}
+		return nil, errors.Wrap(err, "save trading rule")
+	}
+
+	e.mu.Lock()
+	rules := make([]Rule, 0, len(e.rules)+1)
+	for _, r := range e.rules {
+		if r.ID != result.ID {
+			rules = append(rules, r)
+		}
+	}
+	rules = append(rules, *result)
+	e.rules = rules
+	e.mu.Unlock()
+
+	return result, nil
+}
@@ -0,0 +1,96 @@
+package trading
+
+import (
+	"time"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/pkg/errors"
+)
+
+type Kind string
+
+const (
+	// Block forbids exchanges in the direction.
+	Block Kind = "block"
+	// Cap limits the amount of a single exchange in the direction.
+	Cap Kind = "cap"
+)
+
+var (
+	ErrPairBlocked    = errors.New("exchange direction is blocked")
+	ErrAmountExceeded = errors.New("amount exceeds the limit")
+	ErrInvalidRule    = errors.New("invalid trading rule")
+	ErrRuleNotFound   = errors.New("trading rule not found")
+)
+
+// Rule restricts exchanges from From to To currency. An empty currency matches any,
+// so a rule with only To set forbids buying the currency while still allowing to sell it.
+type Rule struct {
+	ID        int64                         `json:"id" db:"id"`
+	From      currency_helpers.CurrencyCode `json:"from,omitempty" db:"from_currency"`
+	To        currency_helpers.CurrencyCode `json:"to,omitempty" db:"to_currency"`
+	Kind      Kind                          `json:"kind" db:"kind"`
+	MaxAmount *currency_helpers.Decimal     `json:"maxAmount,omitempty" db:"max_amount"`
+	Reason    string                        `json:"reason,omitempty" db:"reason"`
+	CreatedAt time.Time                     `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time                     `json:"updatedAt" db:"updated_at"`
+}
+
+func (r Rule) Matches(from, to currency_helpers.CurrencyCode) bool {
+	return (r.From == "" || r.From == from) && (r.To == "" || r.To == to)
+}
+
+func (r Rule) Validate() error {
+	if r.From == "" && r.To == "" {
+		return errors.Wrap(ErrInvalidRule, "at least one of from and to is required")
+	}
+	if r.From != "" && r.From == r.To {
+		return errors.Wrap(ErrInvalidRule, "from and to must differ")
+	}
+
+	switch r.Kind {
+	case Block:
+		if r.MaxAmount != nil {
+			return errors.Wrap(ErrInvalidRule, "block rule cannot have max amount")
+		}
+	case Cap:
+		if r.MaxAmount == nil || !r.MaxAmount.IsPositive() {
+			return errors.Wrap(ErrInvalidRule, "cap rule requires positive max amount")
+		}
+	default:
+		return errors.Wrapf(ErrInvalidRule, "unknown kind '%s'", r.Kind)
+	}
+
+	return nil
+}
+
+// Evaluate checks the exchange of amount from one currency to another against the rules.
+// A nil amount checks the direction only, so caps do not apply.
+func Evaluate(rules []Rule, from, to currency_helpers.CurrencyCode, amount *currency_helpers.Decimal) error {
+	for _, rule := range rules {
+		if !rule.Matches(from, to) {
+			continue
+		}
+
+		switch rule.Kind {
+		case Block:
+			return errors.Wrapf(ErrPairBlocked, "%s to %s%s", from, to, reasonSuffix(rule))
+		case Cap:
+			if amount != nil && amount.GreaterThan(*rule.MaxAmount) {
+				return errors.Wrapf(
+					ErrAmountExceeded, "%s to %s is limited to %s %s%s",
+					from, to, rule.MaxAmount, from, reasonSuffix(rule),
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+func reasonSuffix(rule Rule) string {
+	if rule.Reason == "" {
+		return ""
+	}
+	return ": " + rule.Reason
+}
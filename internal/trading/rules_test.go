@@ -0,0 +1,93 @@
+package trading
+
+import (
+	"testing"
+	"wallet-service/internal/currency_helpers"
+
+	"github.com/pkg/errors"
+)
+
+func amount(t *testing.T, s string) *currency_helpers.Decimal {
+	t.Helper()
+	d, err := currency_helpers.ParseDecimal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &d
+}
+
+func TestEvaluate(t *testing.T) {
+	rules := []Rule{
+		{From: "USD", To: "RUB", Kind: Block, Reason: "sanctions"},
+		{To: "BTC", Kind: Cap, MaxAmount: amount(t, "1000")},
+		{From: "EUR", Kind: Cap, MaxAmount: amount(t, "500")},
+		{From: "JPY", To: "GBP", Kind: Cap, MaxAmount: amount(t, "100000")},
+		{From: "JPY", To: "GBP", Kind: Block},
+	}
+
+	tests := []struct {
+		name     string
+		from, to currency_helpers.CurrencyCode
+		amount   *currency_helpers.Decimal
+		err      error
+		message  string
+	}{
+		{name: "no rule", from: "USD", to: "EUR", amount: amount(t, "1000000")},
+		{name: "blocked", from: "USD", to: "RUB", err: ErrPairBlocked,
+			message: "USD to RUB: sanctions: exchange direction is blocked"},
+		{name: "blocked with amount", from: "USD", to: "RUB", amount: amount(t, "1"), err: ErrPairBlocked},
+		{name: "reverse of blocked", from: "RUB", to: "USD", amount: amount(t, "1")},
+		{name: "wildcard from under cap", from: "USD", to: "BTC", amount: amount(t, "1000")},
+		{name: "wildcard from over cap", from: "GBP", to: "BTC", amount: amount(t, "1000.01"), err: ErrAmountExceeded,
+			message: "GBP to BTC is limited to 1000 GBP: amount exceeds the limit"},
+		{name: "wildcard to over cap", from: "EUR", to: "USD", amount: amount(t, "501"), err: ErrAmountExceeded},
+		{name: "wildcard to under cap", from: "EUR", to: "RUB", amount: amount(t, "499")},
+		{name: "nil amount skips caps", from: "EUR", to: "BTC"},
+		{name: "cap before block", from: "JPY", to: "GBP", amount: amount(t, "200000"), err: ErrAmountExceeded},
+		{name: "block after a passed cap", from: "JPY", to: "GBP", amount: amount(t, "1"), err: ErrPairBlocked},
+		{name: "block after a skipped cap", from: "JPY", to: "GBP", err: ErrPairBlocked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Evaluate(rules, tt.from, tt.to, tt.amount)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if tt.message != "" && err.Error() != tt.message {
+				t.Errorf("got message %q, want %q", err.Error(), tt.message)
+			}
+		})
+	}
+}
+
+func TestRuleValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		ok   bool
+	}{
+		{"block", Rule{From: "USD", To: "RUB", Kind: Block}, true},
+		{"block from any", Rule{To: "RUB", Kind: Block}, true},
+		{"block to any", Rule{From: "RUB", Kind: Block}, true},
+		{"cap", Rule{From: "USD", Kind: Cap, MaxAmount: amount(t, "0.01")}, true},
+		{"any to any", Rule{Kind: Block}, false},
+		{"same currency", Rule{From: "USD", To: "USD", Kind: Block}, false},
+		{"block with max amount", Rule{From: "USD", Kind: Block, MaxAmount: amount(t, "10")}, false},
+		{"cap without max amount", Rule{From: "USD", Kind: Cap}, false},
+		{"cap with zero max amount", Rule{From: "USD", Kind: Cap, MaxAmount: amount(t, "0")}, false},
+		{"cap with negative max amount", Rule{From: "USD", Kind: Cap, MaxAmount: amount(t, "-5")}, false},
+		{"unknown kind", Rule{From: "USD", Kind: "limit"}, false},
+		{"missing kind", Rule{From: "USD"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+			if tt.ok && err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrInvalidRule) {
+				t.Errorf("got error %v, want %v", err, ErrInvalidRule)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package wallet
+
+import (
+	"time"
+	"wallet-service/internal/currency_helpers"
+)
+
+type Wallet struct {
+	ID        int64     `json:"id" db:"id"`
+	UserID    string    `json:"userId" db:"user_id"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	Accounts  []Account `json:"accounts" db:"-"`
+}
+
+// Account is a wallet sub-account holding the balance in a single currency.
+type Account struct {
+	ID        int64                         `json:"id" db:"id"`
+	WalletID  int64                         `json:"walletId" db:"wallet_id"`
+	Currency  currency_helpers.CurrencyCode `json:"currency" db:"currency"`
+	Balance   currency_helpers.Decimal      `json:"balance" db:"balance"`
+	CreatedAt time.Time                     `json:"createdAt" db:"created_at"`
+}
+
+type Balance struct {
+	Currency currency_helpers.CurrencyCode `json:"currency" db:"currency"`
+	Balance  currency_helpers.Decimal      `json:"balance" db:"balance"`
+}
+
+// Exchange is a conversion between two accounts of a wallet.
+// Rate is the market rate the trade was priced with and EffectiveRate is the rate
+// after the spread, Fee is the spread amount in the target currency.
+type Exchange struct {
+	ID            int64                         `json:"id" db:"id"`
+	WalletID      int64                         `json:"walletId" db:"wallet_id"`
+	EntryID       int64                         `json:"entryId" db:"entry_id"`
+	FromCurrency  currency_helpers.CurrencyCode `json:"from" db:"from_currency"`
+	ToCurrency    currency_helpers.CurrencyCode `json:"to" db:"to_currency"`
+	FromAmount    currency_helpers.Decimal      `json:"fromAmount" db:"from_amount"`
+	ToAmount      currency_helpers.Decimal      `json:"toAmount" db:"to_amount"`
+	Rate          currency_helpers.Decimal      `json:"rate" db:"rate"`
+	EffectiveRate currency_helpers.Decimal      `json:"effectiveRate" db:"effective_rate"`
+	Spread        currency_helpers.Decimal      `json:"spread" db:"spread"`
+	Fee           currency_helpers.Decimal      `json:"fee" db:"fee"`
+	RateDate      time.Time                     `json:"rateDate" db:"rate_date"`
+	RateSource    string                        `json:"rateSource" db:"rate_source"`
+	CreatedAt     time.Time                     `json:"createdAt" db:"created_at"`
+}
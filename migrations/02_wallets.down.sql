@@ -0,0 +1,6 @@
+begin;
+
+drop table if exists wallet_accounts;
+drop table if exists wallets;
+
+commit;
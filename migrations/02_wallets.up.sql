@@ -0,0 +1,22 @@
+begin;
+
+create table if not exists wallets
+(
+    id serial primary key,
+    user_id varchar(64) not null check (user_id <> ''),
+    created_at timestamptz not null default now()
+);
+
+create index if not exists wallets_user_id_idx on wallets (user_id);
+
+create table if not exists wallet_accounts
+(
+    id serial primary key,
+    wallet_id int not null references wallets (id) on delete cascade,
+    currency varchar(3) not null check (currency <> ''),
+    balance numeric(38, 8) not null default 0 check (balance >= 0),
+    created_at timestamptz not null default now(),
+    unique (wallet_id, currency)
+);
+
+commit;
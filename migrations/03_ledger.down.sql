@@ -0,0 +1,12 @@
+begin;
+
+drop table if exists postings;
+drop table if exists journal_entries;
+
+delete from wallet_accounts where wallet_id in (select id from wallets where system);
+delete from wallets where system;
+alter table wallets drop column if exists system;
+
+alter table wallet_accounts add constraint wallet_accounts_balance_check check (balance >= 0);
+
+commit;
@@ -0,0 +1,31 @@
+begin;
+
+alter table wallets add column if not exists system bool not null default false;
+
+-- system accounts hold the other side of deposits, withdrawals and exchanges
+-- and may go negative, non-negative user balances are checked by the ledger
+alter table wallet_accounts drop constraint if exists wallet_accounts_balance_check;
+
+insert into wallets (user_id, system) values ('system:external', true), ('system:exchange', true);
+
+create table if not exists journal_entries
+(
+    id bigserial primary key,
+    kind varchar(16) not null check (kind in ('deposit', 'withdrawal', 'transfer', 'exchange')),
+    description text not null default '',
+    created_at timestamptz not null default now()
+);
+
+create table if not exists postings
+(
+    id bigserial primary key,
+    entry_id bigint not null references journal_entries (id),
+    account_id int not null references wallet_accounts (id),
+    currency varchar(3) not null check (currency <> ''),
+    amount numeric(38, 8) not null check (amount <> 0)
+);
+
+create index if not exists postings_entry_id_idx on postings (entry_id);
+create index if not exists postings_account_id_idx on postings (account_id);
+
+commit;
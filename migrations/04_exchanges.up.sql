@@ -0,0 +1,23 @@
+begin;
+
+create table if not exists exchanges
+(
+    id bigserial primary key,
+    wallet_id int not null references wallets (id),
+    entry_id bigint not null references journal_entries (id),
+    from_currency varchar(3) not null check (from_currency <> ''),
+    to_currency varchar(3) not null check (to_currency <> ''),
+    from_amount numeric(38, 8) not null check (from_amount > 0),
+    to_amount numeric(38, 8) not null check (to_amount > 0),
+    rate numeric(38, 18) not null check (rate > 0),
+    effective_rate numeric(38, 18) not null check (effective_rate > 0),
+    spread numeric(7, 4) not null default 0,
+    fee numeric(38, 8) not null default 0,
+    rate_date date not null,
+    rate_source varchar(255) not null default '',
+    created_at timestamptz not null default now()
+);
+
+create index if not exists exchanges_wallet_id_idx on exchanges (wallet_id);
+
+commit;
@@ -0,0 +1,17 @@
+begin;
+
+create table if not exists currency_bans
+(
+    id serial primary key,
+    currency varchar(3) not null unique check (currency <> ''),
+    banned bool not null
+);
+
+insert into currency_bans (currency, banned)
+select code, banned
+from currencies
+where banned;
+
+drop table if exists currencies;
+
+commit;
@@ -0,0 +1,200 @@
+begin;
+
+create table if not exists currencies
+(
+    code varchar(3) primary key check (code ~ '^[A-Z]{3}$'),
+    numeric_code varchar(3) not null default '',
+    name_en text not null check (name_en <> ''),
+    name_ru text not null default '',
+    symbol text not null default '',
+    minor_units int not null check (minor_units between 0 and 8),
+    crypto bool not null default false,
+    non_iso bool not null default false,
+    enabled bool not null default true,
+    banned bool not null default false,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
+
+insert into currencies (code, numeric_code, name_en, name_ru, symbol, minor_units, crypto, non_iso)
+values
+    ('AED', '784', 'UAE Dirham', 'Дирхам ОАЭ', 'د.إ', 2, false, false),
+    ('AFN', '971', 'Afghani', 'Афгани', '؋', 2, false, false),
+    ('ALL', '008', 'Lek', 'Лек', 'L', 2, false, false),
+    ('AMD', '051', 'Armenian Dram', 'Армянский драм', '֏', 2, false, false),
+    ('ANG', '532', 'Netherlands Antillean Guilder', 'Нидерландский антильский гульден', 'ƒ', 2, false, false),
+    ('AOA', '973', 'Kwanza', 'Кванза', 'Kz', 2, false, false),
+    ('ARS', '032', 'Argentine Peso', 'Аргентинское песо', '$', 2, false, false),
+    ('AUD', '036', 'Australian Dollar', 'Австралийский доллар', 'A$', 2, false, false),
+    ('AWG', '533', 'Aruban Florin', 'Арубанский флорин', 'ƒ', 2, false, false),
+    ('AZN', '944', 'Azerbaijan Manat', 'Азербайджанский манат', '₼', 2, false, false),
+    ('BAM', '977', 'Convertible Mark', 'Конвертируемая марка', 'KM', 2, false, false),
+    ('BBD', '052', 'Barbados Dollar', 'Барбадосский доллар', 'Bds$', 2, false, false),
+    ('BDT', '050', 'Taka', 'Така', '৳', 2, false, false),
+    ('BGN', '975', 'Bulgarian Lev', 'Болгарский лев', 'лв', 2, false, false),
+    ('BHD', '048', 'Bahraini Dinar', 'Бахрейнский динар', '.د.ب', 3, false, false),
+    ('BIF', '108', 'Burundi Franc', 'Бурундийский франк', 'FBu', 0, false, false),
+    ('BMD', '060', 'Bermudian Dollar', 'Бермудский доллар', '$', 2, false, false),
+    ('BND', '096', 'Brunei Dollar', 'Брунейский доллар', 'B$', 2, false, false),
+    ('BOB', '068', 'Boliviano', 'Боливиано', 'Bs', 2, false, false),
+    ('BRL', '986', 'Brazilian Real', 'Бразильский реал', 'R$', 2, false, false),
+    ('BSD', '044', 'Bahamian Dollar', 'Багамский доллар', 'B$', 2, false, false),
+    ('BTC', '', 'Bitcoin', 'Биткоин', '₿', 8, true, true),
+    ('BTN', '064', 'Ngultrum', 'Нгултрум', 'Nu.', 2, false, false),
+    ('BWP', '072', 'Pula', 'Пула', 'P', 2, false, false),
+    ('BYN', '933', 'Belarusian Ruble', 'Белорусский рубль', 'Br', 2, false, false),
+    ('BZD', '084', 'Belize Dollar', 'Белизский доллар', 'BZ$', 2, false, false),
+    ('CAD', '124', 'Canadian Dollar', 'Канадский доллар', 'C$', 2, false, false),
+    ('CDF', '976', 'Congolese Franc', 'Конголезский франк', 'FC', 2, false, false),
+    ('CHF', '756', 'Swiss Franc', 'Швейцарский франк', 'Fr', 2, false, false),
+    ('CLF', '990', 'Unidad de Fomento', 'Условная расчётная единица Чили', 'UF', 4, false, true),
+    ('CLP', '152', 'Chilean Peso', 'Чилийское песо', '$', 0, false, false),
+    ('CNH', '', 'Offshore Yuan Renminbi', 'Офшорный китайский юань', '¥', 2, false, true),
+    ('CNY', '156', 'Yuan Renminbi', 'Китайский юань', '¥', 2, false, false),
+    ('COP', '170', 'Colombian Peso', 'Колумбийское песо', '$', 2, false, false),
+    ('CRC', '188', 'Costa Rican Colon', 'Костариканский колон', '₡', 2, false, false),
+    ('CUC', '931', 'Peso Convertible', 'Кубинское конвертируемое песо', 'CUC$', 2, false, false),
+    ('CUP', '192', 'Cuban Peso', 'Кубинское песо', '₱', 2, false, false),
+    ('CVE', '132', 'Cabo Verde Escudo', 'Эскудо Кабо-Верде', 'Esc', 2, false, false),
+    ('CZK', '203', 'Czech Koruna', 'Чешская крона', 'Kč', 2, false, false),
+    ('DJF', '262', 'Djibouti Franc', 'Франк Джибути', 'Fdj', 0, false, false),
+    ('DKK', '208', 'Danish Krone', 'Датская крона', 'kr', 2, false, false),
+    ('DOP', '214', 'Dominican Peso', 'Доминиканское песо', 'RD$', 2, false, false),
+    ('DZD', '012', 'Algerian Dinar', 'Алжирский динар', 'د.ج', 2, false, false),
+    ('EGP', '818', 'Egyptian Pound', 'Египетский фунт', 'E£', 2, false, false),
+    ('ERN', '232', 'Nakfa', 'Накфа', 'Nfk', 2, false, false),
+    ('ETB', '230', 'Ethiopian Birr', 'Эфиопский быр', 'Br', 2, false, false),
+    ('EUR', '978', 'Euro', 'Евро', '€', 2, false, false),
+    ('FJD', '242', 'Fiji Dollar', 'Доллар Фиджи', 'FJ$', 2, false, false),
+    ('FKP', '238', 'Falkland Islands Pound', 'Фунт Фолклендских островов', '£', 2, false, false),
+    ('GBP', '826', 'Pound Sterling', 'Фунт стерлингов', '£', 2, false, false),
+    ('GEL', '981', 'Lari', 'Лари', '₾', 2, false, false),
+    ('GGP', '', 'Guernsey Pound', 'Гернсийский фунт', '£', 2, false, true),
+    ('GHS', '936', 'Ghana Cedi', 'Ганский седи', 'GH₵', 2, false, false),
+    ('GIP', '292', 'Gibraltar Pound', 'Гибралтарский фунт', '£', 2, false, false),
+    ('GMD', '270', 'Dalasi', 'Даласи', 'D', 2, false, false),
+    ('GNF', '324', 'Guinean Franc', 'Гвинейский франк', 'FG', 0, false, false),
+    ('GTQ', '320', 'Quetzal', 'Кетсаль', 'Q', 2, false, false),
+    ('GYD', '328', 'Guyana Dollar', 'Гайанский доллар', 'G$', 2, false, false),
+    ('HKD', '344', 'Hong Kong Dollar', 'Гонконгский доллар', 'HK$', 2, false, false),
+    ('HNL', '340', 'Lempira', 'Лемпира', 'L', 2, false, false),
+    ('HRK', '191', 'Kuna', 'Хорватская куна', 'kn', 2, false, false),
+    ('HTG', '332', 'Gourde', 'Гурд', 'G', 2, false, false),
+    ('HUF', '348', 'Forint', 'Форинт', 'Ft', 2, false, false),
+    ('IDR', '360', 'Rupiah', 'Индонезийская рупия', 'Rp', 2, false, false),
+    ('ILS', '376', 'New Israeli Sheqel', 'Новый израильский шекель', '₪', 2, false, false),
+    ('IMP', '', 'Manx Pound', 'Фунт острова Мэн', '£', 2, false, true),
+    ('INR', '356', 'Indian Rupee', 'Индийская рупия', '₹', 2, false, false),
+    ('IQD', '368', 'Iraqi Dinar', 'Иракский динар', 'ع.د', 3, false, false),
+    ('IRR', '364', 'Iranian Rial', 'Иранский риал', '﷼', 2, false, false),
+    ('ISK', '352', 'Iceland Krona', 'Исландская крона', 'kr', 0, false, false),
+    ('JEP', '', 'Jersey Pound', 'Джерсийский фунт', '£', 2, false, true),
+    ('JMD', '388', 'Jamaican Dollar', 'Ямайский доллар', 'J$', 2, false, false),
+    ('JOD', '400', 'Jordanian Dinar', 'Иорданский динар', 'د.ا', 3, false, false),
+    ('JPY', '392', 'Yen', 'Японская иена', '¥', 0, false, false),
+    ('KES', '404', 'Kenyan Shilling', 'Кенийский шиллинг', 'KSh', 2, false, false),
+    ('KGS', '417', 'Som', 'Киргизский сом', 'сом', 2, false, false),
+    ('KHR', '116', 'Riel', 'Риель', '៛', 2, false, false),
+    ('KMF', '174', 'Comorian Franc', 'Коморский франк', 'CF', 0, false, false),
+    ('KPW', '408', 'North Korean Won', 'Северокорейская вона', '₩', 2, false, false),
+    ('KRW', '410', 'Won', 'Южнокорейская вона', '₩', 0, false, false),
+    ('KWD', '414', 'Kuwaiti Dinar', 'Кувейтский динар', 'د.ك', 3, false, false),
+    ('KYD', '136', 'Cayman Islands Dollar', 'Доллар Каймановых островов', 'CI$', 2, false, false),
+    ('KZT', '398', 'Tenge', 'Казахстанский тенге', '₸', 2, false, false),
+    ('LAK', '418', 'Lao Kip', 'Лаосский кип', '₭', 2, false, false),
+    ('LBP', '422', 'Lebanese Pound', 'Ливанский фунт', 'ل.ل', 2, false, false),
+    ('LKR', '144', 'Sri Lanka Rupee', 'Шри-ланкийская рупия', 'Rs', 2, false, false),
+    ('LRD', '430', 'Liberian Dollar', 'Либерийский доллар', 'L$', 2, false, false),
+    ('LSL', '426', 'Loti', 'Лоти', 'L', 2, false, false),
+    ('LYD', '434', 'Libyan Dinar', 'Ливийский динар', 'ل.د', 3, false, false),
+    ('MAD', '504', 'Moroccan Dirham', 'Марокканский дирхам', 'د.م.', 2, false, false),
+    ('MDL', '498', 'Moldovan Leu', 'Молдавский лей', 'L', 2, false, false),
+    ('MGA', '969', 'Malagasy Ariary', 'Малагасийский ариари', 'Ar', 2, false, false),
+    ('MKD', '807', 'Denar', 'Македонский денар', 'ден', 2, false, false),
+    ('MMK', '104', 'Kyat', 'Кьят', 'K', 2, false, false),
+    ('MNT', '496', 'Tugrik', 'Тугрик', '₮', 2, false, false),
+    ('MOP', '446', 'Pataca', 'Патака', 'MOP$', 2, false, false),
+    ('MRU', '929', 'Ouguiya', 'Угия', 'UM', 2, false, false),
+    ('MUR', '480', 'Mauritius Rupee', 'Маврикийская рупия', '₨', 2, false, false),
+    ('MVR', '462', 'Rufiyaa', 'Руфия', 'Rf', 2, false, false),
+    ('MWK', '454', 'Malawi Kwacha', 'Малавийская квача', 'MK', 2, false, false),
+    ('MXN', '484', 'Mexican Peso', 'Мексиканское песо', '$', 2, false, false),
+    ('MYR', '458', 'Malaysian Ringgit', 'Малайзийский ринггит', 'RM', 2, false, false),
+    ('MZN', '943', 'Mozambique Metical', 'Мозамбикский метикал', 'MT', 2, false, false),
+    ('NAD', '516', 'Namibia Dollar', 'Доллар Намибии', 'N$', 2, false, false),
+    ('NGN', '566', 'Naira', 'Найра', '₦', 2, false, false),
+    ('NIO', '558', 'Cordoba Oro', 'Золотая кордоба', 'C$', 2, false, false),
+    ('NOK', '578', 'Norwegian Krone', 'Норвежская крона', 'kr', 2, false, false),
+    ('NPR', '524', 'Nepalese Rupee', 'Непальская рупия', 'Rs', 2, false, false),
+    ('NZD', '554', 'New Zealand Dollar', 'Новозеландский доллар', 'NZ$', 2, false, false),
+    ('OMR', '512', 'Rial Omani', 'Оманский риал', 'ر.ع.', 3, false, false),
+    ('PAB', '590', 'Balboa', 'Бальбоа', 'B/.', 2, false, false),
+    ('PEN', '604', 'Sol', 'Перуанский соль', 'S/', 2, false, false),
+    ('PGK', '598', 'Kina', 'Кина', 'K', 2, false, false),
+    ('PHP', '608', 'Philippine Peso', 'Филиппинское песо', '₱', 2, false, false),
+    ('PKR', '586', 'Pakistan Rupee', 'Пакистанская рупия', 'Rs', 2, false, false),
+    ('PLN', '985', 'Zloty', 'Польский злотый', 'zł', 2, false, false),
+    ('PYG', '600', 'Guarani', 'Гуарани', '₲', 0, false, false),
+    ('QAR', '634', 'Qatari Rial', 'Катарский риал', 'ر.ق', 2, false, false),
+    ('RON', '946', 'Romanian Leu', 'Румынский лей', 'lei', 2, false, false),
+    ('RSD', '941', 'Serbian Dinar', 'Сербский динар', 'дин.', 2, false, false),
+    ('RUB', '643', 'Russian Ruble', 'Российский рубль', '₽', 2, false, false),
+    ('RWF', '646', 'Rwanda Franc', 'Франк Руанды', 'FRw', 0, false, false),
+    ('SAR', '682', 'Saudi Riyal', 'Саудовский риял', 'ر.س', 2, false, false),
+    ('SBD', '090', 'Solomon Islands Dollar', 'Доллар Соломоновых Островов', 'SI$', 2, false, false),
+    ('SCR', '690', 'Seychelles Rupee', 'Сейшельская рупия', '₨', 2, false, false),
+    ('SDG', '938', 'Sudanese Pound', 'Суданский фунт', 'ج.س.', 2, false, false),
+    ('SEK', '752', 'Swedish Krona', 'Шведская крона', 'kr', 2, false, false),
+    ('SGD', '702', 'Singapore Dollar', 'Сингапурский доллар', 'S$', 2, false, false),
+    ('SHP', '654', 'Saint Helena Pound', 'Фунт Святой Елены', '£', 2, false, false),
+    ('SLL', '694', 'Leone', 'Леоне', 'Le', 2, false, false),
+    ('SOS', '706', 'Somali Shilling', 'Сомалийский шиллинг', 'Sh', 2, false, false),
+    ('SRD', '968', 'Surinam Dollar', 'Суринамский доллар', '$', 2, false, false),
+    ('SSP', '728', 'South Sudanese Pound', 'Южносуданский фунт', '£', 2, false, false),
+    ('STD', '678', 'Dobra (before 2018)', 'Добра (до 2018 года)', 'Db', 2, false, false),
+    ('STN', '930', 'Dobra', 'Добра', 'Db', 2, false, false),
+    ('SVC', '222', 'El Salvador Colon', 'Сальвадорский колон', '₡', 2, false, false),
+    ('SYP', '760', 'Syrian Pound', 'Сирийский фунт', '£S', 2, false, false),
+    ('SZL', '748', 'Lilangeni', 'Лилангени', 'E', 2, false, false),
+    ('THB', '764', 'Baht', 'Таиландский бат', '฿', 2, false, false),
+    ('TJS', '972', 'Somoni', 'Сомони', 'SM', 2, false, false),
+    ('TMT', '934', 'Turkmenistan New Manat', 'Новый туркменский манат', 'm', 2, false, false),
+    ('TND', '788', 'Tunisian Dinar', 'Тунисский динар', 'د.ت', 3, false, false),
+    ('TOP', '776', 'Pa''anga', 'Паанга', 'T$', 2, false, false),
+    ('TRY', '949', 'Turkish Lira', 'Турецкая лира', '₺', 2, false, false),
+    ('TTD', '780', 'Trinidad and Tobago Dollar', 'Доллар Тринидада и Тобаго', 'TT$', 2, false, false),
+    ('TWD', '901', 'New Taiwan Dollar', 'Новый тайваньский доллар', 'NT$', 2, false, false),
+    ('TZS', '834', 'Tanzanian Shilling', 'Танзанийский шиллинг', 'TSh', 2, false, false),
+    ('UAH', '980', 'Hryvnia', 'Украинская гривна', '₴', 2, false, false),
+    ('UGX', '800', 'Uganda Shilling', 'Угандийский шиллинг', 'USh', 0, false, false),
+    ('USD', '840', 'US Dollar', 'Доллар США', '$', 2, false, false),
+    ('UYU', '858', 'Peso Uruguayo', 'Уругвайское песо', '$U', 2, false, false),
+    ('UZS', '860', 'Uzbekistan Sum', 'Узбекский сум', 'сўм', 2, false, false),
+    ('VES', '928', 'Bolivar Soberano', 'Боливар соберано', 'Bs.S', 2, false, false),
+    ('VND', '704', 'Dong', 'Вьетнамский донг', '₫', 0, false, false),
+    ('VUV', '548', 'Vatu', 'Вату', 'VT', 0, false, false),
+    ('WST', '882', 'Tala', 'Тала', 'WS$', 2, false, false),
+    ('XAF', '950', 'CFA Franc BEAC', 'Франк КФА BEAC', 'FCFA', 0, false, false),
+    ('XAG', '961', 'Silver', 'Серебро', 'XAG', 8, false, false),
+    ('XAU', '959', 'Gold', 'Золото', 'XAU', 8, false, false),
+    ('XCD', '951', 'East Caribbean Dollar', 'Восточнокарибский доллар', 'EC$', 2, false, false),
+    ('XDR', '960', 'SDR (Special Drawing Right)', 'СДР (специальные права заимствования)', 'SDR', 8, false, false),
+    ('XOF', '952', 'CFA Franc BCEAO', 'Франк КФА BCEAO', 'CFA', 0, false, false),
+    ('XPD', '964', 'Palladium', 'Палладий', 'XPD', 8, false, false),
+    ('XPF', '953', 'CFP Franc', 'Франк КФП', '₣', 0, false, false),
+    ('XPT', '962', 'Platinum', 'Платина', 'XPT', 8, false, false),
+    ('YER', '886', 'Yemeni Rial', 'Йеменский риал', '﷼', 2, false, false),
+    ('ZAR', '710', 'Rand', 'Южноафриканский рэнд', 'R', 2, false, false),
+    ('ZMW', '967', 'Zambian Kwacha', 'Замбийская квача', 'ZK', 2, false, false),
+    ('ZWL', '932', 'Zimbabwe Dollar', 'Доллар Зимбабве', 'Z$', 2, false, false)
+on conflict (code) do nothing;
+
+-- ban statuses now live in the catalogue
+update currencies as c
+set banned = cb.banned
+from currency_bans as cb
+where cb.currency = c.code;
+
+drop table if exists currency_bans;
+
+commit;
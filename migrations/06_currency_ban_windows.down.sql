@@ -0,0 +1,18 @@
+begin;
+
+alter table currencies add column if not exists banned bool not null default false;
+
+update currencies as c
+set banned = true
+where exists (
+    select 1
+    from currency_ban_windows as w
+    where w.currency = c.code
+        and w.lifted_at is null
+        and w.starts_at <= now()
+        and (w.ends_at is null or w.ends_at > now())
+);
+
+drop table if exists currency_ban_windows;
+
+commit;
@@ -0,0 +1,29 @@
+begin;
+
+-- ban flags moved from currency_bans to currencies.banned in 05_currencies, which dropped currency_bans,
+-- so the windows are built on top of the flag
+create table if not exists currency_ban_windows
+(
+    id bigserial primary key,
+    currency varchar(3) not null references currencies (code),
+    reason text not null check (reason <> ''),
+    author text not null check (author <> ''),
+    starts_at timestamptz not null default now(),
+    -- null means the ban lasts until it is lifted
+    ends_at timestamptz check (ends_at > starts_at),
+    lifted_at timestamptz,
+    lifted_by text not null default '',
+    created_at timestamptz not null default now()
+);
+
+create index if not exists currency_ban_windows_currency_idx on currency_ban_windows (currency, starts_at);
+
+-- existing bans become indefinite windows
+insert into currency_ban_windows (currency, reason, author)
+select code, 'banned before ban windows were introduced', 'system'
+from currencies
+where banned;
+
+alter table currencies drop column if exists banned;
+
+commit;
@@ -0,0 +1,6 @@
+begin;
+
+drop table if exists currency_ban_events;
+drop function if exists currency_ban_events_append_only();
+
+commit;
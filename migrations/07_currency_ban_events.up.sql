@@ -0,0 +1,43 @@
+begin;
+
+create table if not exists currency_ban_events
+(
+    id bigserial primary key,
+    currency varchar(3) not null references currencies (code),
+    action varchar(8) not null check (action in ('ban', 'lift')),
+    window_id bigint not null references currency_ban_windows (id),
+    reason text not null default '',
+    author text not null check (author <> ''),
+    starts_at timestamptz not null,
+    ends_at timestamptz,
+    created_at timestamptz not null default now()
+);
+
+create index if not exists currency_ban_events_currency_idx on currency_ban_events (currency, created_at);
+create index if not exists currency_ban_events_author_idx on currency_ban_events (author, created_at);
+
+create or replace function currency_ban_events_append_only() returns trigger as
+$$
+begin
+    raise exception 'currency_ban_events is append-only';
+end;
+$$ language plpgsql;
+
+drop trigger if exists currency_ban_events_append_only on currency_ban_events;
+create trigger currency_ban_events_append_only
+    before update or delete on currency_ban_events
+    for each row execute function currency_ban_events_append_only();
+
+-- history of the windows opened before events were recorded
+insert into currency_ban_events (currency, action, window_id, reason, author, starts_at, ends_at, created_at)
+select currency, 'ban', id, reason, author, starts_at, ends_at, created_at
+from currency_ban_windows
+order by id;
+
+insert into currency_ban_events (currency, action, window_id, author, starts_at, ends_at, created_at)
+select currency, 'lift', id, lifted_by, starts_at, ends_at, lifted_at
+from currency_ban_windows
+where lifted_at is not null
+order by lifted_at;
+
+commit;
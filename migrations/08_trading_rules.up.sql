@@ -0,0 +1,19 @@
+begin;
+
+create table if not exists trading_rules
+(
+    id bigserial primary key,
+    -- null matches any currency
+    from_currency varchar(3) references currencies (code),
+    to_currency varchar(3) references currencies (code),
+    kind varchar(8) not null check (kind in ('block', 'cap')),
+    -- cap in the from currency
+    max_amount numeric(38, 8) check (max_amount > 0),
+    reason text not null default '',
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now(),
+    check (from_currency is not null or to_currency is not null),
+    check ((kind = 'cap') = (max_amount is not null))
+);
+
+commit;
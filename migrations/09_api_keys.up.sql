@@ -0,0 +1,19 @@
+begin;
+
+create table if not exists api_keys
+(
+    id bigserial primary key,
+    name text not null,
+    -- the beginning of the key, shown to tell keys apart
+    prefix varchar(16) not null,
+    -- sha-256 of the key, the key itself is only shown once when issued
+    key_hash varchar(64) not null unique,
+    -- requests per minute
+    rate_limit integer not null check (rate_limit > 0),
+    -- requests per utc day
+    daily_quota integer not null check (daily_quota > 0),
+    created_at timestamptz not null default now(),
+    revoked_at timestamptz
+);
+
+commit;
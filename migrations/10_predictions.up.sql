@@ -0,0 +1,27 @@
+begin;
+
+create table if not exists predictions
+(
+    id bigserial primary key,
+    base_currency varchar(3) not null references currencies (code),
+    second_currency varchar(3) not null references currencies (code),
+    model varchar(64) not null,
+    model_version varchar(64) not null default '',
+    generated_at timestamptz not null,
+    -- the first predicted day, a pair is kept predicted by a model once a day and later forecasts of the day are dropped
+    forecast_date date not null,
+    target_date date not null,
+    -- 1 for the first predicted day
+    horizon integer not null check (horizon > 0),
+    rate numeric(38, 18) not null,
+    lower numeric(38, 18),
+    upper numeric(38, 18),
+    -- the rate realized on the target date, null until known or when the provider has none
+    actual numeric(38, 18),
+    evaluated_at timestamptz,
+    unique (base_currency, second_currency, model, forecast_date, target_date)
+);
+
+create index if not exists predictions_pending_idx on predictions (target_date) where evaluated_at is null;
+
+commit;
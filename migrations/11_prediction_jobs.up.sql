@@ -0,0 +1,24 @@
+begin;
+
+create table if not exists prediction_jobs
+(
+    id bigserial primary key,
+    base_currency varchar(3) not null references currencies (code),
+    second_currency varchar(3) not null references currencies (code),
+    model varchar(64) not null,
+    status varchar(16) not null default 'queued' check (status in ('queued', 'running', 'done', 'failed')),
+    error text not null default '',
+    -- the generated forecast, kept for polling clients after it leaves the cache
+    forecast jsonb,
+    created_at timestamptz not null default now(),
+    started_at timestamptz,
+    finished_at timestamptz
+);
+
+-- a pair is generated by a model once at a time, enqueueing it again returns the active job
+create unique index if not exists prediction_jobs_active_idx on prediction_jobs (base_currency, second_currency, model)
+    where status in ('queued', 'running');
+
+create index if not exists prediction_jobs_queued_idx on prediction_jobs (id) where status = 'queued';
+
+commit;
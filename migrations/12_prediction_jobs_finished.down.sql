@@ -0,0 +1,5 @@
+begin;
+
+drop index if exists prediction_jobs_finished_idx;
+
+commit;
@@ -0,0 +1,7 @@
+begin;
+
+-- enqueueing looks up the jobs of the pair and model finished today before adding another one
+create index if not exists prediction_jobs_finished_idx on prediction_jobs (base_currency, second_currency, model, finished_at)
+    where status in ('done', 'failed');
+
+commit;
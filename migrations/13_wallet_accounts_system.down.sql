@@ -0,0 +1,8 @@
+begin;
+
+alter table wallet_accounts drop constraint if exists wallet_accounts_balance_check;
+drop trigger if exists wallet_accounts_copy_system on wallet_accounts;
+drop function if exists wallet_accounts_copy_system();
+alter table wallet_accounts drop column if exists system;
+
+commit;
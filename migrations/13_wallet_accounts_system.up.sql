@@ -0,0 +1,24 @@
+begin;
+
+-- the system flag of the wallet is copied to its accounts, so the database keeps user balances
+-- non-negative while system accounts, holding the other side of deposits, withdrawals and exchanges, may go negative
+alter table wallet_accounts add column if not exists system bool not null default false;
+
+update wallet_accounts as a set system = w.system from wallets as w where w.id = a.wallet_id;
+
+-- a missing wallet copies false and the insert fails on the foreign key
+create or replace function wallet_accounts_copy_system() returns trigger as $$
+begin
+    new.system := coalesce((select w.system from wallets as w where w.id = new.wallet_id), false);
+    return new;
+end;
+$$ language plpgsql;
+
+drop trigger if exists wallet_accounts_copy_system on wallet_accounts;
+create trigger wallet_accounts_copy_system before insert or update of wallet_id, system on wallet_accounts
+    for each row execute function wallet_accounts_copy_system();
+
+alter table wallet_accounts drop constraint if exists wallet_accounts_balance_check;
+alter table wallet_accounts add constraint wallet_accounts_balance_check check (system or balance >= 0);
+
+commit;